@@ -20,6 +20,7 @@ import (
 	"log"
 	"os"
 	"sort"
+	"time"
 
 	"github.com/jollheef/henhouse/config"
 	"github.com/jollheef/henhouse/db"
@@ -42,17 +43,28 @@ var (
 	taskAddXML = taskAdd.Arg("xml", "Path to xml.").Required().String()
 
 	taskUpdate    = task.Command("update", "Update task.")
-	taskUpdateID  = taskUpdate.Arg("id", "ID of task.").Required().Int()
+	taskUpdateID  = taskUpdate.Arg("id", "ID or slug of task.").Required().String()
 	taskUpdateXML = taskUpdate.Arg("xml", "Path to xml.").Required().String()
 
 	taskOpen   = task.Command("open", "Open task.")
-	taskOpenID = taskOpen.Arg("id", "ID of task").Required().Int()
+	taskOpenID = taskOpen.Arg("id", "ID or slug of task").Required().String()
 
 	taskClose   = task.Command("close", "Close task.")
-	taskCloseID = taskClose.Arg("id", "ID of task").Required().Int()
+	taskCloseID = taskClose.Arg("id", "ID or slug of task").Required().String()
 
 	taskDump   = task.Command("dump", "Dump task to xml.")
-	taskDumpID = taskDump.Arg("id", "ID of task").Required().Int()
+	taskDumpID = taskDump.Arg("id", "ID or slug of task").Required().String()
+
+	taskSync       = task.Command("sync", "Add new and update changed tasks from directory.")
+	taskSyncDir    = taskSync.Arg("dir", "Path to task directory (task_dir from config by default).").String()
+	taskSyncDryRun = taskSync.Flag("dry-run", "Only show changes.").Bool()
+
+	taskSchedule     = task.Command("schedule", "Schedule release of task.")
+	taskScheduleID   = taskSchedule.Arg("id", "ID or slug of task.").Required().String()
+	taskScheduleTime = taskSchedule.Arg("time", "Time of release in RFC3339 or in timezone of event (remove schedule if empty).").String()
+
+	taskLint    = task.Command("lint", "Validate tasks in directory.")
+	taskLintDir = taskLint.Arg("dir", "Path to task directory.").Required().String()
 
 	// Category
 	category = kingpin.Command("category", "Work with categories.")
@@ -69,6 +81,59 @@ var (
 	teamInfo   = team.Command("info", "Information about team.")
 	teamInfoID = teamInfo.Arg("id", "ID of task").Required().Int()
 
+	teamBan           = team.Command("ban", "Ban team, banned team can not log in and submit flags.")
+	teamBanID         = teamBan.Arg("id", "ID of team.").Required().Int()
+	teamBanReason     = teamBan.Arg("reason", "Reason of ban.").String()
+	teamBanDisqualify = teamBan.Flag("disqualify", "Also hide team from scoreboard.").Bool()
+
+	teamUnban   = team.Command("unban", "Unban and requalify team.")
+	teamUnbanID = teamUnban.Arg("id", "ID of team.").Required().Int()
+
+	// Game, named gameCmd because of game package
+	gameCmd = kingpin.Command("game", "Work with game timing.")
+
+	gameInfo = gameCmd.Command("info", "Show game timing.")
+
+	gameExtend         = gameCmd.Command("extend", "Move end of game.")
+	gameExtendDuration = gameExtend.Arg("duration", "Duration, use -- before negative value.").Required().Duration()
+
+	gamePause  = gameCmd.Command("pause", "Pause game, flags are rejected until resume.")
+	gameResume = gameCmd.Command("resume", "Resume game, end of game is moved by duration of pause.")
+
+	gameFreeze       = gameCmd.Command("freeze", "Hide solves from public scoreboard before end of game.")
+	gameFreezeBefore = gameFreeze.Arg("before", "Duration before end of game, 0 disables freeze.").Required().Duration()
+
+	gameUnfreeze = gameCmd.Command("unfreeze", "Show real scoreboard to public.")
+
+	// Award
+	award = kingpin.Command("award", "Work with manual score adjustments.")
+
+	awardAdd       = award.Command("add", "Grant (or deduct with negative points) points to team.")
+	awardAddTeamID = awardAdd.Arg("team", "ID of team.").Required().Int()
+	awardAddPoints = awardAdd.Arg("points", "Points, use -- before negative value.").Required().Int()
+	awardAddReason = awardAdd.Arg("reason", "Reason of award.").Required().String()
+
+	awardList = award.Command("list", "List awards.")
+
+	awardDelete   = award.Command("delete", "Delete award.")
+	awardDeleteID = awardDelete.Arg("id", "ID of award.").Required().Int()
+
+	// Flag
+	flag = kingpin.Command("flag", "Work with flags.")
+
+	flagGenerate       = flag.Command("generate", "Generate dynamic flags of task.")
+	flagGenerateTaskID = flagGenerate.Arg("task", "ID or slug of task.").Required().String()
+	flagGenerateTeamID = flagGenerate.Arg("team", "ID of team (all teams by default).").Int()
+
+	// Audit
+	audit       = kingpin.Command("audit", "Report suspicious activity of teams.")
+	auditWindow = audit.Flag("window", "Max interval between solves of same task.").Default("30s").Duration()
+
+	// Config
+	configCmd       = kingpin.Command("config", "Work with daemon configuration.")
+	configCheck     = configCmd.Command("check", "Validate daemon configuration.")
+	configCheckPath = configCheck.Arg("path", "Path to daemon configuration file.").Default("/etc/henhouse.toml").String()
+
 	// Export
 	export               = kingpin.Command("export", "Export scoreboard for ctftime.")
 	exportWithLastAccept = export.Flag("with-last-accept", "Add last-accept field.").Bool()
@@ -83,6 +148,9 @@ var (
 	BuildTime string
 )
 
+// location is timezone of event from config
+var location = time.Local
+
 func getCategoryByID(categoryID int, categories []db.Category) string {
 	for _, cat := range categories {
 		if cat.ID == categoryID {
@@ -120,26 +188,17 @@ func parseTask(path string, categories []db.Category) (t db.Task, err error) {
 		return
 	}
 
-	t.Name = task.Name
-	t.Desc = task.Description
-	t.NameEn = task.NameEn
-	t.DescEn = task.DescriptionEn
-	t.CategoryID, err = getCategoryByName(task.Category, categories)
+	if task.Slug == "" {
+		task.Slug = config.SlugFromPath(path)
+	}
+
+	categoryID, err := getCategoryByName(task.Category, categories)
 	if err != nil {
 		log.Println("Cant find category")
 		return
 	}
 
-	t.Level = task.Level
-	t.Flag = task.Flag
-	t.Price = 500         // TODO support non-shared task
-	t.Shared = true       // TODO support non-shared task
-	t.MaxSharePrice = 500 // TODO support value from xml
-	t.MinSharePrice = 100 // TODO support value from xml
-	t.Opened = false      // by default task is closed
-	t.Author = task.Author
-	t.Tags = task.Tags
-	t.ForceClosed = task.ForceClosed
+	t = game.TaskFromConfig(task, categoryID)
 
 	return
 }
@@ -148,12 +207,13 @@ var cfgFiles = []string{"/etc/henhouse/cli.toml", "/etc/henhouse.toml",
 	"cli.toml", "henhouse.toml"}
 
 func taskUpdateCmd(database *sql.DB, categories []db.Category) (err error) {
-	task, err := db.GetTask(database, *taskUpdateID)
+	task, err := db.FindTask(database, *taskUpdateID)
 	if err != nil {
 		return
 	}
 
 	id := task.ID
+	slug := task.Slug // slug is a key, so keep it
 
 	task, err = parseTask(*taskUpdateXML, categories)
 	if err != nil {
@@ -161,6 +221,9 @@ func taskUpdateCmd(database *sql.DB, categories []db.Category) (err error) {
 	}
 
 	task.ID = id
+	if slug != "" {
+		task.Slug = slug
+	} // task created before slugs gets slug from xml
 
 	err = db.UpdateTask(database, &task)
 	if err != nil {
@@ -170,6 +233,20 @@ func taskUpdateCmd(database *sql.DB, categories []db.Category) (err error) {
 	return
 }
 
+func setOpenedCmd(database *sql.DB, key string, opened bool) (err error) {
+	task, err := db.FindTask(database, key)
+	if err != nil {
+		return
+	}
+
+	err = db.SetOpened(database, task.ID, opened)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
 func taskAddCmd(database *sql.DB, categories []db.Category) (err error) {
 	t, err := parseTask(*taskAddXML, categories)
 	if err != nil {
@@ -195,9 +272,11 @@ func taskListCmd(database *sql.DB, categories []db.Category) (err error) {
 	table := tablewriter.NewWriter(os.Stdout)
 	var header []string
 	if *taskListWOFlags {
-		header = []string{"ID", "Name", "Category", "Opened", "Solved by"}
+		header = []string{"ID", "Slug", "Name", "Category", "Opened",
+			"Solved by"}
 	} else {
-		header = []string{"ID", "Name", "Category", "Flag", "Opened", "Solved by"}
+		header = []string{"ID", "Slug", "Name", "Category", "Flag",
+			"Opened", "Solved by"}
 	}
 	table.SetHeader(header)
 
@@ -211,6 +290,7 @@ func taskListCmd(database *sql.DB, categories []db.Category) (err error) {
 		var row []string
 
 		row = append(row, fmt.Sprintf("%d", task.ID))
+		row = append(row, task.Slug)
 		if *taskListEnglish {
 			row = append(row, task.NameEn)
 		} else {
@@ -232,12 +312,18 @@ func taskListCmd(database *sql.DB, categories []db.Category) (err error) {
 }
 
 func taskDumpCmd(database *sql.DB, categories []db.Category) (err error) {
-	task, err := db.GetTask(database, *taskDumpID)
+	task, err := db.FindTask(database, *taskDumpID)
 	if err != nil {
 		return
 	}
 
+	var openAt *time.Time
+	if !task.OpenAt.IsZero() {
+		openAt = &task.OpenAt
+	}
+
 	xmlTask := config.Task{
+		Slug:          task.Slug,
 		Name:          task.Name,
 		NameEn:        task.NameEn,
 		Description:   task.Desc,
@@ -245,6 +331,12 @@ func taskDumpCmd(database *sql.DB, categories []db.Category) (err error) {
 		Category:      getCategoryByID(task.CategoryID, categories),
 		Level:         task.Level,
 		Flag:          task.Flag,
+		FlagType:      task.FlagType,
+		FlagSecret:    task.FlagSecret,
+		Penalty:       game.PenaltyToConfig(task.Penalty),
+		Requires:      game.RequiredSlugs(task),
+		RequiresAny:   task.RequiresAny,
+		OpenAt:        openAt,
 		Author:        task.Author,
 		ForceClosed:   task.ForceClosed,
 		Tags:          task.Tags,
@@ -261,6 +353,288 @@ func taskDumpCmd(database *sql.DB, categories []db.Category) (err error) {
 	return
 }
 
+func printTaskNames(prefix string, names []string) {
+	for _, name := range names {
+		fmt.Println(prefix, name)
+	}
+}
+
+func taskSyncCmd(database *sql.DB, taskDir string) (err error) {
+	if *taskSyncDir != "" {
+		taskDir = *taskSyncDir
+	}
+
+	tasks, err := config.ReadTaskDir(taskDir)
+	if err != nil {
+		return
+	}
+
+	report, err := game.SyncTasks(database, tasks, *taskSyncDryRun)
+	if err != nil {
+		return
+	}
+
+	if report.Empty() {
+		fmt.Println("Tasks are up to date")
+		return
+	}
+
+	printTaskNames("Add", report.Added)
+	printTaskNames("Update", report.Updated)
+	printTaskNames("Removed from directory (kept in database)",
+		report.Removed)
+
+	return
+}
+
+func taskScheduleCmd(database *sql.DB) (err error) {
+	task, err := db.FindTask(database, *taskScheduleID)
+	if err != nil {
+		return
+	}
+
+	var openAt time.Time
+	if *taskScheduleTime != "" {
+		openAt, err = config.ParseTime(*taskScheduleTime, location)
+		if err != nil {
+			return
+		}
+	}
+
+	err = db.SetOpenAt(database, task.ID, openAt)
+	if err != nil {
+		return
+	}
+
+	if openAt.IsZero() {
+		fmt.Println("Remove schedule of task", task.Name)
+	} else {
+		fmt.Println("Open task", task.Name, "at", formatTime(openAt))
+	}
+
+	return
+}
+
+func taskLintCmd() (err error) {
+	tasks, err := config.ReadTaskDir(*taskLintDir)
+	if err != nil {
+		return
+	}
+
+	problems := game.LintTasks(tasks)
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+
+	if len(problems) != 0 {
+		err = fmt.Errorf("%d problems found", len(problems))
+	}
+
+	return
+}
+
+func flagGenerateCmd(database *sql.DB) (err error) {
+	task, err := db.FindTask(database, *flagGenerateTaskID)
+	if err != nil {
+		return
+	}
+
+	if task.FlagType != game.FlagDynamic {
+		err = errors.New("Task " + task.Slug + " has no dynamic flag")
+		return
+	}
+
+	if *flagGenerateTeamID != 0 {
+		fmt.Println(game.DynamicFlag(task.Flag, task.FlagSecret,
+			*flagGenerateTeamID))
+		return
+	}
+
+	teams, err := db.GetTeams(database)
+	if err != nil {
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Name", "Flag"})
+
+	for _, t := range teams {
+		table.Append([]string{fmt.Sprintf("%d", t.ID), t.Name,
+			game.DynamicFlag(task.Flag, task.FlagSecret, t.ID)})
+	}
+
+	table.Render()
+
+	return
+}
+
+func teamNames(teamIDs []int, teams []db.Team) (names string) {
+	for i, id := range teamIDs {
+		if i != 0 {
+			names += ", "
+		}
+		names += fmt.Sprintf("%s (%d)", getTeamByID(id, teams), id)
+	}
+	return
+}
+
+func getTeamByID(teamID int, teams []db.Team) string {
+	for _, t := range teams {
+		if t.ID == teamID {
+			return t.Name
+		}
+	}
+	return "Unknown"
+}
+
+func auditCmd(database *sql.DB) (err error) {
+	report, err := game.Audit(database, *auditWindow)
+	if err != nil {
+		return
+	}
+
+	teams, err := db.GetTeams(database)
+	if err != nil {
+		return
+	}
+
+	fmt.Println("Teams with same address:")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Address", "Teams"})
+	for _, s := range report.SharedAddrs {
+		table.Append([]string{s.Addr, teamNames(s.TeamIDs, teams)})
+	}
+	table.Render()
+
+	fmt.Println("Teams with same wrong flags:")
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Task ID", "Flag", "Teams"})
+	for _, s := range report.SharedWrongFlags {
+		table.Append([]string{fmt.Sprintf("%d", s.TaskID), s.Flag,
+			teamNames(s.TeamIDs, teams)})
+	}
+	table.Render()
+
+	fmt.Println("Solves of same task in", *auditWindow, "window:")
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Task ID", "First", "Second", "Interval"})
+	for _, s := range report.CloseSolves {
+		table.Append([]string{fmt.Sprintf("%d", s.TaskID),
+			teamNames([]int{s.FirstTeamID}, teams),
+			teamNames([]int{s.SecondTeamID}, teams),
+			s.Interval.String()})
+	}
+	table.Render()
+
+	fmt.Println("Submissions of dynamic flags of other teams:")
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Time", "Task ID", "Team", "Flag owner",
+		"Flag"})
+	for _, a := range report.Alerts {
+		table.Append([]string{formatTime(a.Timestamp),
+			fmt.Sprintf("%d", a.TaskID),
+			teamNames([]int{a.TeamID}, teams),
+			teamNames([]int{a.OwnerID}, teams), a.Flag})
+	}
+	table.Render()
+
+	return
+}
+
+// formatTime returns time in timezone of event
+func formatTime(t time.Time) string {
+	return t.In(location).Format(time.RFC3339)
+}
+
+func gameFreezeCmd(database *sql.DB) (err error) {
+	state, err := db.GetGame(database)
+	if err != nil {
+		return
+	}
+
+	var freeze time.Time
+	if *gameFreezeBefore != 0 {
+		freeze = state.End.Add(-*gameFreezeBefore)
+		fmt.Println("Freeze:", formatTime(freeze))
+	}
+
+	return game.SetFreeze(database, freeze)
+}
+
+func gameInfoCmd(database *sql.DB) (err error) {
+	state, err := db.GetGame(database)
+	if err != nil {
+		return
+	}
+
+	fmt.Println("Start:", formatTime(state.Start))
+	fmt.Println("End:", formatTime(state.End))
+	fmt.Println("Paused:", state.Paused)
+	if state.Paused {
+		fmt.Println("Paused at:", formatTime(state.PausedAt))
+	}
+	if !state.Freeze.IsZero() {
+		fmt.Println("Freeze:", formatTime(state.Freeze))
+		fmt.Println("Unfrozen:", state.Unfrozen)
+	}
+
+	left, started, ended := game.Left(state, time.Now())
+	if !started {
+		fmt.Println("Left before start:", left)
+	} else if !ended {
+		fmt.Println("Left before end:", left)
+	} else {
+		fmt.Println("Game is over")
+	}
+
+	return
+}
+
+func awardAddCmd(database *sql.DB) (err error) {
+
+	t, err := db.GetTeam(database, *awardAddTeamID)
+	if err != nil {
+		return
+	}
+
+	a := db.Award{TeamID: t.ID, Points: *awardAddPoints,
+		Reason: *awardAddReason}
+
+	err = db.AddAward(database, &a)
+	if err != nil {
+		return
+	}
+
+	fmt.Printf("Award %d: %d points to %s (%d)\n", a.ID, a.Points,
+		t.Name, t.ID)
+
+	return
+}
+
+func awardListCmd(database *sql.DB) (err error) {
+	awards, err := db.GetAwards(database)
+	if err != nil {
+		return
+	}
+
+	teams, err := db.GetTeams(database)
+	if err != nil {
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Time", "Team", "Points", "Reason"})
+	for _, a := range awards {
+		table.Append([]string{fmt.Sprintf("%d", a.ID),
+			formatTime(a.Timestamp),
+			teamNames([]int{a.TeamID}, teams),
+			fmt.Sprintf("%d", a.Points), a.Reason})
+	}
+	table.Render()
+
+	return
+}
+
 func categoryListCmd(database *sql.DB) (err error) {
 	categories, err := db.GetCategories(database)
 	if err != nil {
@@ -324,7 +698,7 @@ func teamListCmd(database *sql.DB) (err error) {
 
 		solvedCount := 0
 		for _, f := range flags {
-			if f.TeamID == t.ID {
+			if f.TeamID == t.ID && f.Solved {
 				solvedCount++
 			}
 		}
@@ -361,18 +735,28 @@ func teamInfoCmd(database *sql.DB) (err error) {
 		return
 	}
 
+	awards, err := db.GetAwards(database)
+	if err != nil {
+		return
+	}
+
 	for _, t := range teams {
 		if t.ID == *teamInfoID {
 			fmt.Println("ID:", t.ID)
 			fmt.Println("Name:", t.Name)
 			fmt.Println("Email:", t.Email)
 			fmt.Println("Description:", t.Desc)
-			fmt.Println("Token:", t.Token)
+			fmt.Println("Token hash:", t.Token)
 			fmt.Println("Test:", t.Test)
+			fmt.Println("Banned:", t.Banned)
+			fmt.Println("Disqualified:", t.Disqualified)
+			if t.Reason != "" {
+				fmt.Println("Reason:", t.Reason)
+			}
 			fmt.Print("Solved: ")
 			solvedCount := 0
 			for _, f := range flags {
-				if f.TeamID == t.ID {
+				if f.TeamID == t.ID && f.Solved {
 					var task db.Task
 					task, err = db.GetTask(database, f.TaskID)
 					if err != nil {
@@ -384,57 +768,65 @@ func teamInfoCmd(database *sql.DB) (err error) {
 			}
 			fmt.Println()
 			fmt.Printf("Solved: %d/%d\n", solvedCount, len(tasks))
+			for _, a := range awards {
+				if a.TeamID == t.ID {
+					fmt.Printf("Award: %d (%s, %s)\n", a.Points,
+						a.Reason, formatTime(a.Timestamp))
+				}
+			}
 		}
 	}
 
 	return
 }
 
-func exportScoreboard(database *sql.DB) (err error) {
-
-	scores := []game.TeamScoreInfo{}
+func teamBanCmd(database *sql.DB) (err error) {
 
-	teams, err := db.GetTeams(database)
+	t, err := db.GetTeam(database, *teamBanID)
 	if err != nil {
 		return
 	}
 
-	flags, err := db.GetFlags(database)
+	err = db.SetBanned(database, t.ID, true, *teamBanDisqualify,
+		*teamBanReason)
 	if err != nil {
 		return
 	}
 
-	for _, team := range teams {
+	fmt.Printf("Team %s (%d) banned\n", t.Name, t.ID)
+	if *teamBanDisqualify {
+		fmt.Println("Team hidden from scoreboard")
+	}
 
-		if team.Test {
-			continue
-		}
+	return
+}
 
-		var s db.Score
-		s, err = db.GetLastScore(database, team.ID)
-		if err != nil {
-			return
-		}
+func exportScoreboard(database *sql.DB) (err error) {
+
+	state, err := db.GetGame(database)
+	if err == sql.ErrNoRows {
+		err = nil // game never started, so scoreboard is not frozen
+	}
+	if err != nil {
+		return
+	}
+
+	scores, err := game.PublicScoreboard(database, state)
+	if err != nil {
+		return
+	}
 
+	for i := range scores {
 		var bName []byte
 
-		bName, err = json.Marshal(team.Name)
+		bName, err = json.Marshal(scores[i].Name)
 		if err != nil {
 			return
 		}
 
-		scoreInfo := game.TeamScoreInfo{
-			ID:         team.ID,
-			Name:       string(bName),
-			Score:      s.Score,
-			LastAccept: game.LastAccept(team.ID, flags),
-		}
-
-		scores = append(scores, scoreInfo)
+		scores[i].Name = string(bName)
 	}
 
-	sort.Sort(game.ByScoreAndLastAccept(scores))
-
 	fmt.Println("{\n\t\"standings\": [")
 	for i, s := range scores {
 		if *exportWithLastAccept {
@@ -457,7 +849,26 @@ func exportScoreboard(database *sql.DB) (err error) {
 	return
 }
 
-func runCommandLine(database *sql.DB, categories []db.Category) (err error) {
+func configCheckCmd() (err error) {
+	cfg, err := config.ReadConfig(*configCheckPath)
+	if err != nil {
+		return
+	}
+
+	errs := cfg.Validate()
+	for _, e := range errs {
+		fmt.Println(e)
+	}
+
+	if len(errs) != 0 {
+		err = fmt.Errorf("%d problems found", len(errs))
+	}
+
+	return
+}
+
+func runCommandLine(database *sql.DB, categories []db.Category,
+	cfg config.Config) (err error) {
 	switch kingpin.Parse() {
 	case "task add":
 		err = taskAddCmd(database, categories)
@@ -466,11 +877,15 @@ func runCommandLine(database *sql.DB, categories []db.Category) (err error) {
 	case "task list":
 		err = taskListCmd(database, categories)
 	case "task open":
-		err = db.SetOpened(database, *taskOpenID, true)
+		err = setOpenedCmd(database, *taskOpenID, true)
 	case "task close":
-		err = db.SetOpened(database, *taskCloseID, false)
+		err = setOpenedCmd(database, *taskCloseID, false)
 	case "task dump":
 		err = taskDumpCmd(database, categories)
+	case "task sync":
+		err = taskSyncCmd(database, cfg.TaskDir)
+	case "task schedule":
+		err = taskScheduleCmd(database)
 	case "category add":
 		err = db.AddCategory(database, &db.Category{Name: *categoryName})
 	case "category list":
@@ -479,6 +894,32 @@ func runCommandLine(database *sql.DB, categories []db.Category) (err error) {
 		err = teamListCmd(database)
 	case "team info":
 		err = teamInfoCmd(database)
+	case "team ban":
+		err = teamBanCmd(database)
+	case "team unban":
+		err = db.SetBanned(database, *teamUnbanID, false, false, "")
+	case "game info":
+		err = gameInfoCmd(database)
+	case "game extend":
+		err = game.Extend(database, *gameExtendDuration)
+	case "game pause":
+		err = game.Pause(database)
+	case "game resume":
+		err = game.Resume(database)
+	case "game freeze":
+		err = gameFreezeCmd(database)
+	case "game unfreeze":
+		err = game.Unfreeze(database)
+	case "award add":
+		err = awardAddCmd(database)
+	case "award list":
+		err = awardListCmd(database)
+	case "award delete":
+		err = db.DeleteAward(database, *awardDeleteID)
+	case "flag generate":
+		err = flagGenerateCmd(database)
+	case "audit":
+		err = auditCmd(database)
 	case "export":
 		err = exportScoreboard(database)
 	}
@@ -495,7 +936,21 @@ func main() {
 	kingpin.Version(BuildDate + " " + CommitID +
 		" (Mikhail Klementyev <jollheef@riseup.net>)")
 
-	kingpin.Parse()
+	// offline checks work without config and database, e.g. in CI
+	switch kingpin.Parse() {
+	case "config check":
+		err := configCheckCmd()
+		if err != nil {
+			log.Fatalln("Error:", err)
+		}
+		return
+	case "task lint":
+		err := taskLintCmd()
+		if err != nil {
+			log.Fatalln("Error:", err)
+		}
+		return
+	}
 
 	var cfgPath string
 
@@ -521,6 +976,8 @@ func main() {
 		log.Fatalln("Cannot open config:", err)
 	}
 
+	location = cfg.Game.Location
+
 	database, err := db.OpenDatabase(cfg.Database.Connection)
 	if err != nil {
 		log.Fatalln("Error:", err)
@@ -535,7 +992,7 @@ func main() {
 		log.Fatalln("Error:", err)
 	}
 
-	err = runCommandLine(database, categories)
+	err = runCommandLine(database, categories, cfg)
 	if err != nil {
 		log.Fatalln("Error:", err)
 	}
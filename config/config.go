@@ -13,6 +13,9 @@ package config
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/naoina/toml"
 )
@@ -21,6 +24,11 @@ import (
 type Config struct {
 	// All logs redirected to file
 	LogFile string
+	// Auth and flags, log_file with -audit suffix by default
+	AuditLogFile string
+	// Level (debug, info, warn, error) and format (text, json) of logs
+	LogLevel  string
+	LogFormat string
 
 	// Path to directory contains task xml files
 	TaskDir string
@@ -37,6 +45,28 @@ type Config struct {
 		Addr          string
 		RecalcTimeout _duration
 		UnderProxy    bool
+		// Networks of proxies allowed to pass client address, loopback
+		// if under proxy and not set
+		TrustedProxies []string
+		// Header with client address set by proxy (X-Real-IP,
+		// X-Forwarded-For or Forwarded), X-Real-IP if not set
+		ProxyHeader string
+		// Timeouts of http server
+		ReadTimeout  _duration
+		WriteTimeout _duration
+		IdleTimeout  _duration
+		// Max wait for requests and websockets on shutdown
+		ShutdownTimeout _duration
+		// Enable https if both set, reloaded on SIGHUP
+		TLSCert string
+		TLSKey  string
+		// Address of http server redirects to https
+		RedirectAddr string
+	}
+
+	Metrics struct {
+		// Admin address of /metrics endpoint, disabled if not set
+		Addr string
 	}
 
 	WebsocketTimeout struct {
@@ -53,13 +83,23 @@ type Config struct {
 	}
 
 	Game struct {
+		// Time zone of event, used for times without zone and output
+		Timezone string
+		Location *time.Location `toml:"-"`
+
 		Start _time
 		End   _time
+		// Public scoreboard hides solves after freeze
+		Freeze _time
 	}
 
 	Flag struct {
 		// Timeout between send flags
 		SendTimeout _duration
+		// Points for each wrong flag, zero disables penalties
+		Penalty int
+		// Max penalty of team for one task, zero means no limit
+		PenaltyCap int
 	}
 
 	Task struct {
@@ -68,6 +108,11 @@ type Config struct {
 		// Auto open task after previous solved
 		AutoOpen        bool
 		AutoOpenTimeout _duration
+		// Open next task only for team solved previous
+		PerTeam bool
+		// Add new and update changed tasks from task directory
+		AutoSync        bool
+		AutoSyncTimeout _duration
 	}
 
 	Teams []struct {
@@ -78,6 +123,9 @@ type Config struct {
 	}
 }
 
+// DefaultTimezone used if [Game] timezone is not set
+const DefaultTimezone = "Europe/Moscow"
+
 // ReadConfig read file and return configuration
 func ReadConfig(path string) (cfg Config, err error) {
 
@@ -97,5 +145,34 @@ func ReadConfig(path string) (cfg Config, err error) {
 		return
 	}
 
+	err = cfg.ApplyEnv()
+	if err != nil {
+		return
+	}
+
+	if cfg.AuditLogFile == "" && cfg.LogFile != "" {
+		ext := filepath.Ext(cfg.LogFile)
+		cfg.AuditLogFile = strings.TrimSuffix(cfg.LogFile, ext) +
+			"-audit" + ext
+	}
+
+	if cfg.Game.Timezone == "" {
+		cfg.Game.Timezone = DefaultTimezone
+	}
+
+	cfg.Game.Location, err = time.LoadLocation(cfg.Game.Timezone)
+	if err != nil {
+		return
+	}
+
+	for _, t := range []*_time{&cfg.Game.Start, &cfg.Game.End,
+		&cfg.Game.Freeze} {
+
+		err = t.setLocation(cfg.Game.Location)
+		if err != nil {
+			return
+		}
+	}
+
 	return
 }
@@ -61,3 +61,62 @@ func TestReadInvalidConfig(*testing.T) {
 		panic(errors.New("Ok read invalid config"))
 	}
 }
+
+func TestReadConfigTimezone(*testing.T) {
+
+	configPath := "/tmp/timezone-config"
+
+	err := ioutil.WriteFile(configPath, []byte(`
+[Game]
+timezone = "Asia/Tokyo"
+start = "Nov 17 10:00 2015"
+end = "2015-12-31T23:59:00+03:00"
+`), 0644)
+	if err != nil {
+		panic(err)
+	}
+
+	cfg, err := ReadConfig(configPath)
+	if err != nil {
+		panic(err)
+	}
+
+	bugOnInvalid("2015-11-17 10:00:00 +0900 JST", cfg.Game.Start.String())
+
+	bugOnInvalid("2016-01-01 05:59:00 +0900 JST", cfg.Game.End.String())
+
+	if !cfg.Game.Freeze.IsZero() {
+		panic(errors.New("Freeze without value"))
+	}
+
+	err = ioutil.WriteFile(configPath, []byte(`
+[Game]
+timezone = "Mars/Olympus"
+`), 0644)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = ReadConfig(configPath)
+	if err == nil {
+		panic(errors.New("Ok read config with invalid timezone"))
+	}
+}
+
+func TestReadConfigAuditLog(*testing.T) {
+
+	configPath := "/tmp/audit-log-config"
+
+	err := ioutil.WriteFile(configPath,
+		[]byte(`log_file = "/var/log/henhouse.log"`), 0644)
+	if err != nil {
+		panic(err)
+	}
+
+	cfg, err := ReadConfig(configPath)
+	if err != nil {
+		panic(err)
+	}
+
+	bugOnInvalid("/var/log/henhouse-audit.log", cfg.AuditLogFile)
+}
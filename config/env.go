@@ -0,0 +1,167 @@
+/**
+ * @file env.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief override configuration from environment
+ *
+ * Contain functions for override values of configuration file by
+ * environment variables (HENHOUSE_DATABASE_CONNECTION) or by content of
+ * files (HENHOUSE_DATABASE_CONNECTION_FILE) for secrets, lists of strings
+ * are comma-separated (HENHOUSE_SCOREBOARD_TRUSTED_PROXIES=10.0.0.0/8,::1)
+ */
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix is prefix of environment variables
+const EnvPrefix = "HENHOUSE"
+
+type unmarshaler interface {
+	UnmarshalTOML(data []byte) error
+}
+
+// EnvName convert name of field (MaxConnections) to part of environment
+// variable name (MAX_CONNECTIONS)
+func EnvName(field string) string {
+	var name []rune
+	runes := []rune(field)
+	for i, r := range runes {
+		// word starts after lower letter or at end of acronym (TLSCert)
+		if i != 0 && unicode.IsUpper(r) && (!unicode.IsUpper(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			name = append(name, '_')
+		}
+		name = append(name, unicode.ToUpper(r))
+	}
+	return string(name)
+}
+
+// lookupEnv returns value of variable or content of file from variable
+// with _FILE suffix, value of variable has priority
+func lookupEnv(name string) (value string, found bool, err error) {
+
+	value, found = os.LookupEnv(name)
+	if found {
+		return
+	}
+
+	path, found := os.LookupEnv(name + "_FILE")
+	if !found {
+		return
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	value = strings.TrimRight(string(content), "\r\n")
+	return
+}
+
+func setFromEnv(v reflect.Value, name string) (err error) {
+
+	value, found, err := lookupEnv(name)
+	if err != nil || !found {
+		return
+	}
+
+	if u, ok := v.Addr().Interface().(unmarshaler); ok {
+		err = u.UnmarshalTOML([]byte(value))
+	} else {
+		err = setValue(v, value)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("%s: %s", name, err)
+	}
+
+	return
+}
+
+func setValue(v reflect.Value, value string) (err error) {
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int:
+		var i int64
+		i, err = strconv.ParseInt(value, 10, 0)
+		v.SetInt(i)
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(value)
+		v.SetBool(b)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			err = fmt.Errorf("unsupported type %s", v.Type())
+			return
+		}
+
+		list := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, s := range strings.Split(value, ",") {
+			s = strings.TrimSpace(s)
+			if s != "" {
+				list = reflect.Append(list, reflect.ValueOf(s))
+			}
+		}
+		v.Set(list)
+	default:
+		err = fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return
+}
+
+func applyEnv(v reflect.Value, name string) (err error) {
+
+	if _, ok := v.Addr().Interface().(unmarshaler); ok {
+		return setFromEnv(v, name)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" || field.Tag.Get("toml") == "-" {
+				continue
+			}
+
+			err = applyEnv(v.Field(i), name+"_"+EnvName(field.Name))
+			if err != nil {
+				return
+			}
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String {
+			return setFromEnv(v, name)
+		}
+
+		// only existing entries, e.g. HENHOUSE_TEAMS_0_TOKEN
+		for i := 0; i < v.Len(); i++ {
+			err = applyEnv(v.Index(i), fmt.Sprintf("%s_%d", name, i))
+			if err != nil {
+				return
+			}
+		}
+	default:
+		err = setFromEnv(v, name)
+	}
+
+	return
+}
+
+// ApplyEnv override values of configuration by environment variables
+func (cfg *Config) ApplyEnv() (err error) {
+	return applyEnv(reflect.ValueOf(cfg).Elem(), EnvPrefix)
+}
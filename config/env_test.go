@@ -0,0 +1,81 @@
+/**
+ * @file env_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief test override configuration from environment
+ */
+
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEnvName(*testing.T) {
+
+	bugOnInvalid("MAX_CONNECTIONS", EnvName("MaxConnections"))
+	bugOnInvalid("P500", EnvName("P500"))
+	bugOnInvalid("LOG_FILE", EnvName("LogFile"))
+	bugOnInvalid("TLS_CERT", EnvName("TLSCert"))
+}
+
+func TestReadConfigEnv(*testing.T) {
+
+	secretPath := "/tmp/henhouse-secret"
+
+	err := ioutil.WriteFile(secretPath, []byte("secret\n"), 0600)
+	if err != nil {
+		panic(err)
+	}
+
+	env := map[string]string{
+		"HENHOUSE_DATABASE_CONNECTION_FILE":   secretPath,
+		"HENHOUSE_DATABASE_MAX_CONNECTIONS":   "10",
+		"HENHOUSE_SCOREBOARD_UNDER_PROXY":     "false",
+		"HENHOUSE_TASK_OPEN_TIMEOUT":          "2m",
+		"HENHOUSE_GAME_START":                 "2015-11-17T12:00:00+03:00",
+		"HENHOUSE_TEAMS_1_TOKEN":              "pewpew",
+		"HENHOUSE_SCOREBOARD_TRUSTED_PROXIES": "10.0.0.0/8, ::1,",
+	}
+
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	cfg, err := ReadConfig("henhouse.toml")
+	if err != nil {
+		panic(err)
+	}
+
+	bugOnInvalid("secret", cfg.Database.Connection)
+
+	if cfg.Database.MaxConnections != 10 || cfg.Scoreboard.UnderProxy {
+		panic(errors.New("Parsed invalid value"))
+	}
+
+	bugOnInvalid("2m0s", cfg.Task.OpenTimeout.String())
+
+	bugOnInvalid("2015-11-17 12:00:00 +0300 MSK", cfg.Game.Start.String())
+
+	bugOnInvalid("pewpew", cfg.Teams[1].Token)
+
+	bugOnInvalid("10.0.0.0/8 ::1",
+		strings.Join(cfg.Scoreboard.TrustedProxies, " "))
+}
+
+func TestReadConfigInvalidEnv(*testing.T) {
+
+	os.Setenv("HENHOUSE_DATABASE_MAX_CONNECTIONS", "ololo")
+	defer os.Unsetenv("HENHOUSE_DATABASE_MAX_CONNECTIONS")
+
+	_, err := ReadConfig("henhouse.toml")
+	if err == nil {
+		panic(errors.New("Ok read config with invalid env"))
+	}
+}
@@ -25,24 +25,45 @@ func (d *_duration) UnmarshalTOML(data []byte) (err error) {
 	return
 }
 
+// legacyTimeLayout is time without zone, zone is set by [Game] timezone
+const legacyTimeLayout = "Jan _2 15:04 2006"
+
+// ParseTime parse time in RFC3339 or legacy layout, result is in location
+func ParseTime(raw string, loc *time.Location) (t time.Time, err error) {
+
+	t, err = time.Parse(time.RFC3339, raw)
+	if err == nil {
+		t = t.In(loc)
+		return
+	}
+
+	return time.ParseInLocation(legacyTimeLayout, raw, loc)
+}
+
 type _time struct {
 	time.Time
+	raw string
 }
 
 func (t *_time) UnmarshalTOML(data []byte) (err error) {
 
-	loc, err := time.LoadLocation("Europe/Moscow")
+	t.raw = strings.Replace(string(data), "\"", "", -1)
+
+	// location is not known yet, see setLocation
+	t.Time, err = ParseTime(t.raw, time.UTC)
 	if err != nil {
 		return
 	}
 
-	rawTime := strings.Replace(string(data), "\"", "", -1)
+	return
+}
+
+func (t *_time) setLocation(loc *time.Location) (err error) {
 
-	layout := "Jan _2 15:04 2006"
-	t.Time, err = time.ParseInLocation(layout, rawTime, loc)
-	if err != nil {
+	if t.raw == "" {
 		return
 	}
 
+	t.Time, err = ParseTime(t.raw, loc)
 	return
 }
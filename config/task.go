@@ -5,31 +5,242 @@
  * @date November, 2015
  * @brief task parser
  *
- * Contain functions for parse task xml
+ * Contain functions for parse task xml and task directories
  */
 
 package config
 
 import (
 	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/naoina/toml"
+	"gopkg.in/yaml.v3"
 )
 
 // Task is xml task data model
 type Task struct {
-	Name          string
-	Description   string
-	NameEn        string
-	DescriptionEn string
-	Category      string
-	Tags          string
-	Level         int
-	ForceClosed   bool
-	Flag          string
-	Author        string
+	// Unique and stable identifier, file name is used if empty
+	Slug          string `yaml:"slug"`
+	Name          string `yaml:"name"`
+	Description   string `yaml:"description"`
+	NameEn        string `yaml:"name_en"`
+	DescriptionEn string `yaml:"description_en"`
+	Category      string `yaml:"category"`
+	Tags          string `yaml:"tags"`
+	Level         int    `yaml:"level"`
+	ForceClosed   bool   `yaml:"force_closed"`
+	Flag          string `yaml:"flag"`
+	FlagType      string `xml:",omitempty" yaml:"flag_type"` // see game
+	FlagSecret    string `xml:",omitempty" yaml:"flag_secret"`
+	ExampleFlag   string `xml:",omitempty" yaml:"example_flag"`
+	Penalty       *int   `xml:",omitempty" yaml:"penalty"` // nil for global
+	Author        string `yaml:"author"`
+	Hints         string `xml:",omitempty" yaml:"hints"`
+
+	// Slugs of tasks must be solved before open, task with requirements
+	// is not a part of level chain of category
+	Requires    []string `xml:",omitempty" yaml:"requires"`
+	RequiresAny bool     `xml:",omitempty" yaml:"requires_any"`
+
+	// Time of release of task (RFC3339), nil if not scheduled
+	OpenAt *time.Time `xml:",omitempty" yaml:"open_at"`
+
+	// Files for participants, relative to task directory
+	Files []string `xml:"-" toml:"-" yaml:"-"`
+	// Directory of task relative to task dir, empty for xml task
+	Dir string `xml:"-" toml:"-" yaml:"-"`
 }
 
+// TaskLoader load task from entry of task directory
+type TaskLoader interface {
+	// Detect returns true if entry contains task for this loader
+	Detect(path string, info os.FileInfo) bool
+	Load(path string) (task Task, err error)
+}
+
+// TaskLoaders used by ReadTaskDir in order of priority
+var TaskLoaders = []TaskLoader{xmlLoader{}, dirLoader{}}
+
 // ParseXMLTask parse xml task
 func ParseXMLTask(rawXML []byte) (task Task, err error) {
 	err = xml.Unmarshal(rawXML, &task)
 	return
 }
+
+// ParseTOMLTask parse toml task
+func ParseTOMLTask(rawTOML []byte) (task Task, err error) {
+	err = toml.Unmarshal(rawTOML, &task)
+	return
+}
+
+// ParseYAMLTask parse yaml task
+func ParseYAMLTask(rawYAML []byte) (task Task, err error) {
+	err = yaml.Unmarshal(rawYAML, &task)
+	return
+}
+
+// xmlLoader load task from single xml file
+type xmlLoader struct{}
+
+func (xmlLoader) Detect(path string, info os.FileInfo) bool {
+	return !info.IsDir()
+}
+
+func (xmlLoader) Load(path string) (task Task, err error) {
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	return ParseXMLTask(content)
+}
+
+// dirLoader load task from directory with task.toml or task.yaml,
+// files/ contains files for participants, solution/ is never loaded
+type dirLoader struct{}
+
+var dirTaskFiles = map[string]func([]byte) (Task, error){
+	"task.toml": ParseTOMLTask,
+	"task.yaml": ParseYAMLTask,
+	"task.yml":  ParseYAMLTask,
+}
+
+func (dirLoader) definition(path string) (name string, err error) {
+
+	for file := range dirTaskFiles {
+		_, err = os.Stat(filepath.Join(path, file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+
+		if name != "" {
+			err = fmt.Errorf("both %s and %s exists", name, file)
+			return
+		}
+
+		name = file
+	}
+
+	err = nil
+	return
+}
+
+func (l dirLoader) Detect(path string, info os.FileInfo) bool {
+	if !info.IsDir() {
+		return false
+	}
+
+	name, err := l.definition(path)
+	return err != nil || name != ""
+}
+
+func (l dirLoader) Load(path string) (task Task, err error) {
+
+	name, err := l.definition(path)
+	if err != nil {
+		return
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(path, name))
+	if err != nil {
+		return
+	}
+
+	task, err = dirTaskFiles[name](content)
+	if err != nil {
+		return
+	}
+
+	hints, err := ioutil.ReadFile(filepath.Join(path, "hints"))
+	if err == nil {
+		task.Hints = strings.TrimSpace(string(hints))
+	} else if !os.IsNotExist(err) {
+		return
+	}
+
+	err = filepath.Walk(filepath.Join(path, "files"),
+		func(file string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			if err != nil || info.IsDir() {
+				return err
+			}
+
+			rel, err := filepath.Rel(path, file)
+			if err != nil {
+				return err
+			}
+
+			task.Files = append(task.Files, rel)
+			return nil
+		})
+
+	return
+}
+
+// SlugFromPath returns file name without extension
+func SlugFromPath(path string) string {
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// ReadTaskDir load all tasks in directory and fill missing slugs,
+// hidden entries and directories without task are skipped
+func ReadTaskDir(dir string) (tasks []Task, err error) {
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+
+		for _, loader := range TaskLoaders {
+
+			if !loader.Detect(path, entry) {
+				continue
+			}
+
+			var task Task
+			task, err = loader.Load(path)
+			if err != nil {
+				err = fmt.Errorf("%s: %s", path, err)
+				return
+			}
+
+			if entry.IsDir() {
+				task.Dir = entry.Name()
+			}
+
+			if task.Slug == "" {
+				if entry.IsDir() {
+					task.Slug = entry.Name()
+				} else {
+					task.Slug = SlugFromPath(path)
+				}
+			}
+
+			tasks = append(tasks, task)
+			break
+		}
+	}
+
+	return
+}
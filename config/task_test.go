@@ -10,6 +10,9 @@ package config
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -68,3 +71,242 @@ func TestParseXML(*testing.T) {
 		panic("invalid parse")
 	}
 }
+
+func TestReadTaskDir(*testing.T) {
+
+	dir, err := ioutil.TempDir("", "henhouse-tasks")
+	if err != nil {
+		panic(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	for i := 0; i < 3; i++ {
+		xml := fmt.Sprintf(`<Task><Name>task%d</Name></Task>`, i)
+
+		err = ioutil.WriteFile(filepath.Join(dir,
+			fmt.Sprintf("task%d.xml", i)), []byte(xml), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	err = os.Mkdir(filepath.Join(dir, "subdir"), 0755)
+	if err != nil {
+		panic(err)
+	}
+
+	tasks, err := ReadTaskDir(dir)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(tasks) != 3 {
+		panic("invalid amount of tasks")
+	}
+
+	for _, task := range tasks {
+		if task.Slug != task.Name {
+			panic("slug not filled from file name")
+		}
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, "invalid.xml"),
+		[]byte("<Task>"), 0644)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = ReadTaskDir(dir)
+	if err == nil {
+		panic("invalid xml parsed")
+	}
+}
+
+func writeTaskFile(path, content string) {
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		panic(err)
+	}
+
+	err = ioutil.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestReadTaskDirLayout(*testing.T) {
+
+	dir, err := ioutil.TempDir("", "henhouse-tasks")
+	if err != nil {
+		panic(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	writeTaskFile(filepath.Join(dir, "web1", "task.toml"), `
+name = "web"
+name_en = "web en"
+category = "web"
+level = 1
+flag = "flag"
+`)
+	writeTaskFile(filepath.Join(dir, "web1", "hints"), "look at headers\n")
+	writeTaskFile(filepath.Join(dir, "web1", "files", "src", "app.py"), "")
+	writeTaskFile(filepath.Join(dir, "web1", "solution", "solve.py"), "")
+
+	writeTaskFile(filepath.Join(dir, "crypto1", "task.yaml"), `
+slug: rsa
+name_en: crypto en
+category: crypto
+level: 2
+flag: flag
+`)
+
+	writeTaskFile(filepath.Join(dir, "empty", "README"), "")
+	writeTaskFile(filepath.Join(dir, ".git", "config"), "")
+
+	tasks, err := ReadTaskDir(dir)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(tasks) != 2 {
+		panic("invalid amount of tasks")
+	}
+
+	for _, task := range tasks {
+		switch task.Slug {
+		case "web1":
+			if task.NameEn != "web en" || task.Level != 1 {
+				panic("invalid parse toml")
+			}
+			if task.Hints != "look at headers" {
+				panic("hints not loaded")
+			}
+			if len(task.Files) != 1 ||
+				task.Files[0] != filepath.Join("files", "src", "app.py") {
+				panic("files not loaded")
+			}
+			if task.Dir != "web1" {
+				panic("invalid task directory")
+			}
+		case "rsa":
+			if task.NameEn != "crypto en" || task.Level != 2 {
+				panic("invalid parse yaml")
+			}
+		default:
+			panic("invalid slug")
+		}
+	}
+
+	writeTaskFile(filepath.Join(dir, "crypto1", "task.toml"), "")
+
+	_, err = ReadTaskDir(dir)
+	if err == nil {
+		panic("ambiguous task definition loaded")
+	}
+}
+
+func TestParseRequires(*testing.T) {
+
+	task, err := ParseXMLTask([]byte(`
+	<Task>
+	  <Requires>web1</Requires>
+	  <Requires>crypto1</Requires>
+	  <RequiresAny>true</RequiresAny>
+	</Task>`))
+	if err != nil {
+		panic(err)
+	}
+
+	if len(task.Requires) != 2 || task.Requires[1] != "crypto1" ||
+		!task.RequiresAny {
+		panic("invalid parse xml requires")
+	}
+
+	task, err = ParseTOMLTask([]byte(`
+requires = ["web1", "crypto1"]
+requires_any = true
+`))
+	if err != nil {
+		panic(err)
+	}
+
+	if len(task.Requires) != 2 || !task.RequiresAny {
+		panic("invalid parse toml requires")
+	}
+
+	task, err = ParseYAMLTask([]byte("requires: [web1]\n"))
+	if err != nil {
+		panic(err)
+	}
+
+	if len(task.Requires) != 1 || task.RequiresAny {
+		panic("invalid parse yaml requires")
+	}
+}
+
+func TestParsePenalty(*testing.T) {
+
+	task, err := ParseXMLTask([]byte(`<Task><Penalty>0</Penalty></Task>`))
+	if err != nil {
+		panic(err)
+	}
+
+	if task.Penalty == nil || *task.Penalty != 0 {
+		panic("invalid parse xml penalty")
+	}
+
+	task, err = ParseTOMLTask([]byte("penalty = 5\n"))
+	if err != nil {
+		panic(err)
+	}
+
+	if task.Penalty == nil || *task.Penalty != 5 {
+		panic("invalid parse toml penalty")
+	}
+
+	task, err = ParseYAMLTask([]byte("penalty: 0\n"))
+	if err != nil {
+		panic(err)
+	}
+
+	if task.Penalty == nil || *task.Penalty != 0 {
+		panic("invalid parse yaml penalty")
+	}
+
+	task, err = ParseXMLTask([]byte(`<Task></Task>`))
+	if err != nil {
+		panic(err)
+	}
+
+	if task.Penalty != nil {
+		panic("penalty without value")
+	}
+}
+
+func TestParseOpenAt(*testing.T) {
+
+	task, err := ParseXMLTask([]byte(`
+	<Task>
+	  <OpenAt>2026-10-20T14:00:00+03:00</OpenAt>
+	</Task>`))
+	if err != nil {
+		panic(err)
+	}
+
+	if task.OpenAt == nil || task.OpenAt.UTC().Hour() != 11 {
+		panic("invalid parse open at")
+	}
+
+	task, err = ParseXMLTask([]byte(`<Task></Task>`))
+	if err != nil {
+		panic(err)
+	}
+
+	if task.OpenAt != nil {
+		panic("open at without schedule")
+	}
+}
@@ -0,0 +1,244 @@
+/**
+ * @file validate.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief validate configuration
+ *
+ * Contain functions for check configuration before start of daemon
+ */
+
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+)
+
+func checkDir(path, name string) (err error) {
+	if path == "" {
+		return fmt.Errorf("%s is not set", name)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%s: %s", name, err)
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%s: %s is not a directory", name, path)
+	}
+
+	return
+}
+
+func (cfg Config) validateLog() (errs []error) {
+	if cfg.LogFile == "" {
+		errs = append(errs, fmt.Errorf("log_file is not set"))
+	} else if cfg.AuditLogFile == cfg.LogFile {
+		errs = append(errs, fmt.Errorf("audit_log_file must differ "+
+			"from log_file"))
+	}
+
+	if cfg.LogLevel != "" {
+		var level slog.Level
+		err := level.UnmarshalText([]byte(cfg.LogLevel))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("log_level: %s", err))
+		}
+	}
+
+	switch cfg.LogFormat {
+	case "", "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("log_format must be text or json"))
+	}
+
+	return
+}
+
+func (cfg Config) validatePaths() (errs []error) {
+
+	for _, dir := range []struct{ path, name string }{
+		{cfg.TaskDir, "task_dir"},
+		{cfg.Scoreboard.WwwPath, "Scoreboard.www_path"},
+		{cfg.Scoreboard.TemplatePath, "Scoreboard.template_path"},
+	} {
+		err := checkDir(dir.path, dir.name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return
+}
+
+func (cfg Config) validateTLS() (errs []error) {
+	cert, key := cfg.Scoreboard.TLSCert, cfg.Scoreboard.TLSKey
+
+	if (cert == "") != (key == "") {
+		errs = append(errs, fmt.Errorf("Scoreboard.tls_cert and "+
+			"Scoreboard.tls_key must be set together"))
+		return
+	}
+
+	if cert == "" {
+		if cfg.Scoreboard.RedirectAddr != "" {
+			errs = append(errs, fmt.Errorf("Scoreboard.redirect_addr "+
+				"requires tls_cert and tls_key"))
+		}
+		return
+	}
+
+	_, err := tls.LoadX509KeyPair(cert, key)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("Scoreboard.tls_cert: %s", err))
+	}
+
+	return
+}
+
+func (cfg Config) validateProxies() (errs []error) {
+	for _, cidr := range cfg.Scoreboard.TrustedProxies {
+		if !strings.Contains(cidr, "/") {
+			if net.ParseIP(cidr) == nil {
+				errs = append(errs, fmt.Errorf("Scoreboard."+
+					"trusted_proxies: invalid address %s", cidr))
+			}
+			continue
+		}
+
+		_, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Scoreboard."+
+				"trusted_proxies: %s", err))
+		}
+	}
+
+	switch strings.ToLower(cfg.Scoreboard.ProxyHeader) {
+	case "", "x-real-ip", "x-forwarded-for", "forwarded":
+	default:
+		errs = append(errs, fmt.Errorf("Scoreboard.proxy_header must be "+
+			"X-Real-IP, X-Forwarded-For or Forwarded"))
+	}
+	return
+}
+
+func (cfg Config) validateGame() (errs []error) {
+	start, end := cfg.Game.Start.Time, cfg.Game.End.Time
+
+	if start.IsZero() {
+		errs = append(errs, fmt.Errorf("Game.start is not set"))
+	}
+
+	if end.IsZero() {
+		errs = append(errs, fmt.Errorf("Game.end is not set"))
+	}
+
+	if !start.IsZero() && !end.IsZero() && !end.After(start) {
+		errs = append(errs, fmt.Errorf("Game.end (%s) is not after "+
+			"Game.start (%s)", end, start))
+	}
+
+	freeze := cfg.Game.Freeze.Time
+	if !freeze.IsZero() && (freeze.Before(start) || freeze.After(end)) {
+		errs = append(errs, fmt.Errorf("Game.freeze (%s) is outside "+
+			"of game", freeze))
+	}
+
+	return
+}
+
+func (cfg Config) validateTaskPrice() (errs []error) {
+	p := cfg.TaskPrice
+
+	if p.P200 <= 0 || p.P300 <= 0 || p.P400 <= 0 || p.P500 <= 0 {
+		errs = append(errs, fmt.Errorf("TaskPrice.p500, p400, p300 "+
+			"and p200 must be positive"))
+	} else if !(p.P500 <= p.P400 && p.P400 <= p.P300 && p.P300 <= p.P200) {
+		errs = append(errs, fmt.Errorf("TaskPrice must be "+
+			"p500 <= p400 <= p300 <= p200"))
+	}
+
+	if p.UseTeamsBase && p.TeamsBase <= 0 {
+		errs = append(errs, fmt.Errorf("TaskPrice.teams_base must be "+
+			"positive if use_teams_base is set"))
+	}
+
+	return
+}
+
+func (cfg Config) validateTeams() (errs []error) {
+	names := make(map[string]bool)
+	tokens := make(map[string]string)
+
+	for i, team := range cfg.Teams {
+		if team.Name == "" {
+			errs = append(errs, fmt.Errorf("Teams[%d]: name is not set",
+				i))
+		} else if names[team.Name] {
+			errs = append(errs, fmt.Errorf("Teams[%d]: duplicate "+
+				"name %s", i, team.Name))
+		}
+		names[team.Name] = true
+
+		if team.Token == "" {
+			errs = append(errs, fmt.Errorf("Teams[%d]: token is not "+
+				"set", i))
+			continue
+		}
+
+		if other, ok := tokens[team.Token]; ok {
+			errs = append(errs, fmt.Errorf("Teams[%d]: token of %s "+
+				"is same as token of %s", i, team.Name, other))
+		}
+		tokens[team.Token] = team.Name
+	}
+
+	return
+}
+
+// Validate returns all problems of configuration
+func (cfg Config) Validate() (errs []error) {
+	if cfg.Database.Connection == "" {
+		errs = append(errs, fmt.Errorf("Database.connection is not set"))
+	}
+
+	if cfg.Database.MaxConnections <= 0 {
+		errs = append(errs, fmt.Errorf("Database.max_connections must "+
+			"be positive"))
+	}
+
+	if cfg.Scoreboard.Addr == "" {
+		errs = append(errs, fmt.Errorf("Scoreboard.addr is not set"))
+	}
+
+	if cfg.Flag.Penalty < 0 || cfg.Flag.PenaltyCap < 0 {
+		errs = append(errs, fmt.Errorf("Flag.penalty and "+
+			"Flag.penalty_cap must not be negative"))
+	}
+
+	if cfg.Task.AutoOpen && cfg.Task.AutoOpenTimeout.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("Task.auto_open_timeout must be "+
+			"positive if auto_open is set"))
+	}
+
+	if cfg.Task.AutoSync && cfg.Task.AutoSyncTimeout.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("Task.auto_sync_timeout must be "+
+			"positive if auto_sync is set"))
+	}
+
+	errs = append(errs, cfg.validateLog()...)
+	errs = append(errs, cfg.validatePaths()...)
+	errs = append(errs, cfg.validateTLS()...)
+	errs = append(errs, cfg.validateProxies()...)
+	errs = append(errs, cfg.validateGame()...)
+	errs = append(errs, cfg.validateTaskPrice()...)
+	errs = append(errs, cfg.validateTeams()...)
+
+	return
+}
@@ -0,0 +1,56 @@
+/**
+ * @file validate_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief test validate configuration
+ */
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func validConfig() (cfg Config) {
+
+	cfg, err := ReadConfig("henhouse.toml")
+	if err != nil {
+		panic(err)
+	}
+
+	cfg.TaskDir = "/tmp"
+	cfg.Scoreboard.WwwPath = "/tmp"
+	cfg.Scoreboard.TemplatePath = "/tmp"
+
+	return
+}
+
+func TestValidate(*testing.T) {
+
+	cfg := validConfig()
+
+	errs := cfg.Validate()
+	if len(errs) != 0 {
+		panic(fmt.Errorf("Valid config has problems: %v", errs))
+	}
+}
+
+func TestValidateInvalid(*testing.T) {
+
+	cfg := validConfig()
+
+	cfg.Database.MaxConnections = 0
+	cfg.Scoreboard.WwwPath = "/dev/ololo/pewpew"
+	cfg.Game.End = cfg.Game.Start
+	cfg.TaskPrice.P300 = 0
+	cfg.Teams[1].Token = cfg.Teams[0].Token
+	cfg.Scoreboard.ProxyHeader = "X-Client-IP"
+
+	errs := cfg.Validate()
+	if len(errs) != 6 {
+		panic(errors.New(fmt.Sprint("Expected 6 problems, got ", errs)))
+	}
+}
@@ -0,0 +1,86 @@
+/**
+ * @file alert.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief queries for alert table
+ */
+
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Alert row, team submit flag of other (owner) team
+type Alert struct {
+	ID        int
+	TeamID    int
+	TaskID    int
+	OwnerID   int
+	Flag      string
+	Timestamp time.Time
+}
+
+func createAlertTable(db *sql.DB) (err error) {
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS "alert" (
+		id		SERIAL PRIMARY KEY,
+		team_id		INTEGER NOT NULL,
+		task_id		INTEGER NOT NULL,
+		owner_id	INTEGER NOT NULL,
+		flag		TEXT NOT NULL,
+		timestamp	TIMESTAMP with time zone DEFAULT now()
+	)`)
+
+	return
+}
+
+// AddAlert add alert to db and fill id
+func AddAlert(db *sql.DB, a *Alert) (err error) {
+
+	stmt, err := db.Prepare("INSERT INTO alert " +
+		"(team_id, task_id, owner_id, flag) " +
+		"VALUES ($1, $2, $3, $4) RETURNING id")
+	if err != nil {
+		return
+	}
+
+	defer stmt.Close()
+
+	err = stmt.QueryRow(a.TeamID, a.TaskID, a.OwnerID,
+		a.Flag).Scan(&a.ID)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// GetAlerts get all alerts in alert table
+func GetAlerts(db *sql.DB) (alerts []Alert, err error) {
+
+	rows, err := db.Query("SELECT id, team_id, task_id, owner_id, flag, " +
+		"timestamp FROM alert")
+	if err != nil {
+		return
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var a Alert
+
+		err = rows.Scan(&a.ID, &a.TeamID, &a.TaskID, &a.OwnerID,
+			&a.Flag, &a.Timestamp)
+		if err != nil {
+			return
+		}
+
+		alerts = append(alerts, a)
+	}
+
+	return
+}
@@ -0,0 +1,65 @@
+/**
+ * @file alert_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief test work with alert table
+ */
+
+package db
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAddAlert(*testing.T) {
+
+	db, err := InitDatabase(dbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	defer db.Close()
+
+	alert := Alert{ID: 255, TeamID: 1, TaskID: 2, OwnerID: 3, Flag: "f"}
+
+	err = AddAlert(db, &alert)
+	if err != nil {
+		panic(err)
+	}
+
+	if alert.ID != 1 {
+		panic(errors.New("Alert id not correct"))
+	}
+
+	alerts, err := GetAlerts(db)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(alerts) != 1 || alerts[0].OwnerID != alert.OwnerID {
+		panic(errors.New("Get invalid alert"))
+	}
+}
+
+// Test work with alerts on closed database
+func TestFailAlert(*testing.T) {
+
+	db, err := InitDatabase(dbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	db.Close()
+
+	err = AddAlert(db, &Alert{})
+	if err == nil {
+		panic(err)
+	}
+
+	_, err = GetAlerts(db)
+	if err == nil {
+		panic(err)
+	}
+}
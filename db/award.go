@@ -0,0 +1,110 @@
+/**
+ * @file award.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief queries for award table
+ */
+
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Award row, manual score adjustment of team (can be negative)
+type Award struct {
+	ID        int
+	TeamID    int
+	Points    int
+	Reason    string
+	Timestamp time.Time
+}
+
+func createAwardTable(db *sql.DB) (err error) {
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS "award" (
+		id		SERIAL PRIMARY KEY,
+		team_id		INTEGER NOT NULL,
+		points		INTEGER NOT NULL,
+		reason		TEXT NOT NULL,
+		timestamp	TIMESTAMP with time zone DEFAULT now()
+	)`)
+
+	return
+}
+
+// AddAward add award to db and fill id
+func AddAward(db *sql.DB, a *Award) (err error) {
+
+	stmt, err := db.Prepare("INSERT INTO award " +
+		"(team_id, points, reason) " +
+		"VALUES ($1, $2, $3) RETURNING id")
+	if err != nil {
+		return
+	}
+
+	defer stmt.Close()
+
+	err = stmt.QueryRow(a.TeamID, a.Points, a.Reason).Scan(&a.ID)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// GetAwards get all awards in award table
+func GetAwards(db *sql.DB) (awards []Award, err error) {
+
+	rows, err := db.Query("SELECT id, team_id, points, reason, " +
+		"timestamp FROM award")
+	if err != nil {
+		return
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var a Award
+
+		err = rows.Scan(&a.ID, &a.TeamID, &a.Points, &a.Reason,
+			&a.Timestamp)
+		if err != nil {
+			return
+		}
+
+		awards = append(awards, a)
+	}
+
+	return
+}
+
+// DeleteAward remove award by id
+func DeleteAward(db *sql.DB, awardID int) (err error) {
+
+	stmt, err := db.Prepare("DELETE FROM award WHERE id=$1")
+	if err != nil {
+		return
+	}
+
+	defer stmt.Close()
+
+	res, err := stmt.Exec(awardID)
+	if err != nil {
+		return
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	if n == 0 {
+		err = sql.ErrNoRows
+	}
+
+	return
+}
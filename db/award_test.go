@@ -0,0 +1,84 @@
+/**
+ * @file award_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief test work with award table
+ */
+
+package db
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAward(*testing.T) {
+
+	db, err := InitDatabase(dbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	defer db.Close()
+
+	award := Award{ID: 255, TeamID: 1, Points: -100, Reason: "r"}
+
+	err = AddAward(db, &award)
+	if err != nil {
+		panic(err)
+	}
+
+	if award.ID != 1 {
+		panic(errors.New("Award id not correct"))
+	}
+
+	awards, err := GetAwards(db)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(awards) != 1 || awards[0].Points != award.Points {
+		panic(errors.New("Get invalid award"))
+	}
+
+	err = DeleteAward(db, award.ID)
+	if err != nil {
+		panic(err)
+	}
+
+	err = DeleteAward(db, award.ID)
+	if err == nil {
+		panic(errors.New("Delete not exist award"))
+	}
+
+	awards, err = GetAwards(db)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(awards) != 0 {
+		panic(errors.New("Award not deleted"))
+	}
+}
+
+// Test work with awards on closed database
+func TestFailAward(*testing.T) {
+
+	db, err := InitDatabase(dbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	db.Close()
+
+	err = AddAward(db, &Award{})
+	if err == nil {
+		panic(err)
+	}
+
+	_, err = GetAwards(db)
+	if err == nil {
+		panic(err)
+	}
+}
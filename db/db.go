@@ -10,14 +10,26 @@
 
 package db
 
-import (
-	"database/sql"
-
-	_ "github.com/lib/pq" // import postgresql db engine
-)
+import "database/sql"
 
 // All table names
-var tables = [...]string{"category", "flag", "score", "session", "task", "team"}
+var tables = [...]string{"alert", "award", "category", "flag", "game",
+	"score", "session", "task", "team", "unlock"}
+
+// addColumns add columns missing in table created by previous version,
+// existing columns are left as is
+func addColumns(db *sql.DB, table string, columns ...string) (err error) {
+
+	for _, column := range columns {
+		_, err = db.Exec("ALTER TABLE " + table +
+			" ADD COLUMN IF NOT EXISTS " + column)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
 
 // Create tables
 func createSchema(db *sql.DB) error {
@@ -29,12 +41,16 @@ func createSchema(db *sql.DB) error {
 
 	var errs []error
 
+	errs = append(errs, createAlertTable(db))
+	errs = append(errs, createAwardTable(db))
 	errs = append(errs, createCategoryTable(db))
 	errs = append(errs, createFlagTable(db))
+	errs = append(errs, createGameTable(db))
 	errs = append(errs, createScoreTable(db))
 	errs = append(errs, createSessionTable(db))
 	errs = append(errs, createTaskTable(db))
 	errs = append(errs, createTeamTable(db))
+	errs = append(errs, createUnlockTable(db))
 
 	for _, e := range errs {
 		if e != nil {
@@ -48,7 +64,7 @@ func createSchema(db *sql.DB) error {
 // OpenDatabase need defer db.Close() after open
 func OpenDatabase(path string) (db *sql.DB, err error) {
 
-	db, err = sql.Open("postgres", path)
+	db, err = sql.Open(driverName, path)
 	if err != nil {
 		return
 	}
@@ -106,7 +122,8 @@ func dropSchema(db *sql.DB) (err error) {
 // InitDatabase recreate all database tables
 func InitDatabase(path string) (db *sql.DB, err error) {
 
-	db, err = OpenDatabase(path)
+	// Schema of previous version is not upgraded before drop
+	db, err = sql.Open(driverName, path)
 	if err != nil {
 		return
 	}
@@ -258,3 +258,97 @@ func TestFailDropSchema(*testing.T) {
 		panic(err)
 	}
 }
+
+// Test open database created by version without added columns
+func TestUpgradeSchema(*testing.T) {
+
+	db, err := InitDatabase(dbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	defer db.Close()
+
+	_, err = db.Exec(`
+	DROP TABLE task, team, session;
+	CREATE TABLE "task" (
+		id		SERIAL PRIMARY KEY,
+		name		TEXT NOT NULL,
+		description	TEXT NOT NULL,
+		name_en		TEXT NOT NULL,
+		description_en	TEXT NOT NULL,
+		tags		TEXT NOT NULL,
+		category_id	INTEGER NOT NULL,
+		level		INTEGER NOT NULL,
+		price		INTEGER NOT NULL,
+		shared		BOOLEAN NOT NULL,
+		flag		TEXT NOT NULL,
+		max_share_price	INTEGER NOT NULL,
+		min_share_price	INTEGER NOT NULL,
+		opened		BOOLEAN NOT NULL,
+		author		TEXT NOT NULL,
+		opened_time	TIMESTAMP with time zone,
+		force_closed	BOOLEAN NOT NULL
+	);
+	CREATE TABLE "team" (
+		id		SERIAL PRIMARY KEY,
+		name		TEXT NOT NULL,
+		email		TEXT NOT NULL,
+		description	TEXT NOT NULL,
+		token		TEXT NOT NULL,
+		test		BOOLEAN NOT NULL
+	);
+	CREATE TABLE "session" (
+		id		SERIAL PRIMARY KEY,
+		team_id		INTEGER NOT NULL,
+		session		TEXT NOT NULL,
+		timestamp	TIMESTAMP with time zone DEFAULT now()
+	);
+	INSERT INTO task (name, description, name_en, description_en, tags,
+		category_id, level, price, shared, flag, max_share_price,
+		min_share_price, opened, author, force_closed)
+	VALUES ('n', 'd', 'ne', 'de', 't', 1, 1, 500, false, 'f', 0, 0,
+		true, 'a', false);
+	INSERT INTO team (name, email, description, token, test)
+	VALUES ('n', 'e', 'd', 't', false);
+	INSERT INTO session (team_id, session) VALUES (1, 's')`)
+	if err != nil {
+		panic(err)
+	}
+
+	// twice, migration must be idempotent
+	for i := 0; i < 2; i++ {
+		err = createSchema(db)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	tasks, err := GetTasks(db)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(tasks) != 1 || tasks[0].Slug != "" || tasks[0].Penalty != 0 ||
+		!tasks[0].OpenAt.IsZero() {
+		panic(fmt.Errorf("Invalid migrated tasks %v", tasks))
+	}
+
+	teams, err := GetTeams(db)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(teams) != 1 || teams[0].Banned || teams[0].Disqualified {
+		panic(fmt.Errorf("Invalid migrated teams %v", teams))
+	}
+
+	sessions, err := GetSessions(db)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(sessions) != 1 || sessions[0].Addr != "" {
+		panic(fmt.Errorf("Invalid migrated sessions %v", sessions))
+	}
+}
@@ -0,0 +1,165 @@
+/**
+ * @file driver.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief measure latency of queries
+ *
+ * Contain postgresql driver wrapper observes duration of each query
+ */
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"github.com/jollheef/henhouse/metrics"
+	"github.com/lib/pq"
+)
+
+const driverName = "postgres-timed"
+
+var queryDuration = metrics.NewHistogram("henhouse_db_query_seconds",
+	"Latency of database queries.", metrics.DefaultBuckets)
+
+func observe(start time.Time) {
+	queryDuration.Observe(time.Since(start).Seconds())
+}
+
+func init() {
+	sql.Register(driverName, timedDriver{pq.Driver{}})
+}
+
+type timedDriver struct {
+	driver.Driver
+}
+
+func (d timedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return timedConn{conn}, nil
+}
+
+// timedConn pass optional interfaces of driver connection
+type timedConn struct {
+	driver.Conn
+}
+
+func (c timedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c timedConn) PrepareContext(ctx context.Context,
+	query string) (stmt driver.Stmt, err error) {
+
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+
+	if err != nil {
+		return
+	}
+
+	return timedStmt{stmt}, nil
+}
+
+func (c timedConn) BeginTx(ctx context.Context,
+	opts driver.TxOptions) (driver.Tx, error) {
+
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c timedConn) QueryContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Rows, error) {
+
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	defer observe(time.Now())
+	return q.QueryContext(ctx, query, args)
+}
+
+func (c timedConn) ExecContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Result, error) {
+
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	defer observe(time.Now())
+	return e.ExecContext(ctx, query, args)
+}
+
+func (c timedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c timedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c timedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c timedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+type timedStmt struct {
+	driver.Stmt
+}
+
+func namedToValues(args []driver.NamedValue) (values []driver.Value) {
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+	return
+}
+
+func (s timedStmt) QueryContext(ctx context.Context,
+	args []driver.NamedValue) (driver.Rows, error) {
+
+	defer observe(time.Now())
+
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return q.QueryContext(ctx, args)
+	}
+	return s.Stmt.Query(namedToValues(args))
+}
+
+func (s timedStmt) ExecContext(ctx context.Context,
+	args []driver.NamedValue) (driver.Result, error) {
+
+	defer observe(time.Now())
+
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return e.ExecContext(ctx, args)
+	}
+	return s.Stmt.Exec(namedToValues(args))
+}
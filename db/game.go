@@ -0,0 +1,99 @@
+/**
+ * @file game.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief queries for game table
+ */
+
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Game row, timing of game, table contains only one row
+type Game struct {
+	ID       int
+	Start    time.Time
+	End      time.Time
+	Paused   bool
+	PausedAt time.Time
+	Freeze   time.Time // public scoreboard hides solves after, zero if none
+	Unfrozen bool
+}
+
+func createGameTable(db *sql.DB) (err error) {
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS "game" (
+		id		SERIAL PRIMARY KEY,
+		start_time	TIMESTAMP with time zone NOT NULL,
+		end_time	TIMESTAMP with time zone NOT NULL,
+		paused		BOOLEAN NOT NULL,
+		paused_at	TIMESTAMP with time zone,
+		freeze		TIMESTAMP with time zone,
+		unfrozen	BOOLEAN NOT NULL
+	)`)
+
+	return
+}
+
+// InitGame add game if there is no game yet, otherwise fill g from db
+func InitGame(db *sql.DB, g *Game) (err error) {
+
+	stmt, err := db.Prepare("INSERT INTO game (start_time, end_time, " +
+		"paused, paused_at, freeze, unfrozen) " +
+		"SELECT $1, $2, $3, $4, $5, $6 " +
+		"WHERE NOT EXISTS (SELECT 1 FROM game)")
+	if err != nil {
+		return
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(g.Start, g.End, g.Paused, g.PausedAt, g.Freeze,
+		g.Unfrozen)
+	if err != nil {
+		return
+	}
+
+	*g, err = GetGame(db)
+	return
+}
+
+// GetGame get timing of game
+func GetGame(db *sql.DB) (g Game, err error) {
+
+	err = db.QueryRow("SELECT id, start_time, end_time, paused, "+
+		"paused_at, freeze, unfrozen FROM game ORDER BY id LIMIT 1").Scan(
+		&g.ID, &g.Start, &g.End, &g.Paused, &g.PausedAt, &g.Freeze,
+		&g.Unfrozen)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// UpdateGame update timing of game
+func UpdateGame(db *sql.DB, g *Game) (err error) {
+
+	stmt, err := db.Prepare("UPDATE game SET start_time=$1, " +
+		"end_time=$2, paused=$3, paused_at=$4, freeze=$5, " +
+		"unfrozen=$6 WHERE id=$7")
+	if err != nil {
+		return
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(g.Start, g.End, g.Paused, g.PausedAt, g.Freeze,
+		g.Unfrozen, g.ID)
+	if err != nil {
+		return
+	}
+
+	return
+}
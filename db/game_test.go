@@ -0,0 +1,59 @@
+/**
+ * @file game_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief test work with game table
+ */
+
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInitGame(*testing.T) {
+
+	db, err := InitDatabase(dbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	defer db.Close()
+
+	start := time.Now().Truncate(time.Second)
+
+	g := Game{Start: start, End: start.Add(time.Hour)}
+
+	err = InitGame(db, &g)
+	if err != nil {
+		panic(err)
+	}
+
+	if g.ID == 0 || !g.Start.Equal(start) {
+		panic(errors.New("Game not added"))
+	}
+
+	g.End = g.End.Add(time.Hour)
+	g.Paused = true
+	g.PausedAt = start
+
+	err = UpdateGame(db, &g)
+	if err != nil {
+		panic(err)
+	}
+
+	// Game already exists, so must not be replaced
+	other := Game{Start: start.Add(time.Hour), End: start.Add(time.Hour)}
+
+	err = InitGame(db, &other)
+	if err != nil {
+		panic(err)
+	}
+
+	if other.ID != g.ID || !other.End.Equal(g.End) || !other.Paused {
+		panic(errors.New("Game replaced"))
+	}
+}
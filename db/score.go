@@ -74,3 +74,26 @@ func GetLastScore(db *sql.DB, teamID int) (s Score, err error) {
 
 	return
 }
+
+// GetLastScoreBefore get last result for team id recorded before time
+func GetLastScoreBefore(db *sql.DB, teamID int, before time.Time) (s Score,
+	err error) {
+
+	stmt, err := db.Prepare("SELECT id, score, timestamp FROM score " +
+		"WHERE team_id=$1 AND timestamp < $2 ORDER BY id DESC LIMIT 1")
+	if err != nil {
+		return
+	}
+
+	defer stmt.Close()
+
+	err = stmt.QueryRow(teamID, before).Scan(&s.ID, &s.Score,
+		&s.Timestamp)
+	if err != nil {
+		return
+	}
+
+	s.TeamID = teamID
+
+	return
+}
@@ -18,6 +18,7 @@ type Session struct {
 	ID        int
 	TeamID    int
 	Session   string
+	Addr      string // client address at login
 	Timestamp time.Time
 }
 
@@ -28,24 +29,28 @@ func createSessionTable(db *sql.DB) (err error) {
 		id		SERIAL PRIMARY KEY,
 		team_id		INTEGER NOT NULL,
 		session		TEXT NOT NULL,
+		addr		TEXT NOT NULL,
 		timestamp	TIMESTAMP with time zone DEFAULT now()
 	)`)
+	if err != nil {
+		return
+	}
 
-	return
+	return addColumns(db, "session", "addr TEXT NOT NULL DEFAULT ''")
 }
 
 // AddSession add session and fill id
 func AddSession(db *sql.DB, s *Session) (err error) {
 
-	stmt, err := db.Prepare("INSERT INTO session (team_id, session) " +
-		"VALUES ($1, $2) RETURNING id")
+	stmt, err := db.Prepare("INSERT INTO session (team_id, session, addr) " +
+		"VALUES ($1, $2, $3) RETURNING id")
 	if err != nil {
 		return
 	}
 
 	defer stmt.Close()
 
-	err = stmt.QueryRow(s.TeamID, s.Session).Scan(&s.ID)
+	err = stmt.QueryRow(s.TeamID, s.Session, s.Addr).Scan(&s.ID)
 	if err != nil {
 		return
 	}
@@ -53,6 +58,32 @@ func AddSession(db *sql.DB, s *Session) (err error) {
 	return
 }
 
+// GetSessions get all sessions
+func GetSessions(db *sql.DB) (sessions []Session, err error) {
+
+	rows, err := db.Query("SELECT id, team_id, session, addr, timestamp " +
+		"FROM session")
+	if err != nil {
+		return
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var s Session
+
+		err = rows.Scan(&s.ID, &s.TeamID, &s.Session, &s.Addr,
+			&s.Timestamp)
+		if err != nil {
+			return
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	return
+}
+
 // GetSessionCount returns count of logged teams for all time
 func GetSessionCount(db *sql.DB) (count int, err error) {
 	err = db.QueryRow("SELECT COUNT(DISTINCT team_id) FROM session;").Scan(&count)
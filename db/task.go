@@ -10,12 +10,14 @@ package db
 
 import (
 	"database/sql"
+	"strconv"
 	"time"
 )
 
 // Task row
 type Task struct {
 	ID            int
+	Slug          string
 	Name          string
 	Desc          string
 	NameEn        string
@@ -27,11 +29,19 @@ type Task struct {
 	Price         int
 	Shared        bool
 	Flag          string
+	FlagType      string
+	FlagSecret    string
+	Penalty       int    // for wrong flag, global if zero, none if negative
+	Requires      string // slugs of required tasks separated by comma
+	RequiresAny   bool   // any of required tasks is enough
 	MaxSharePrice int
 	MinSharePrice int
 	Opened        bool
 	Author        string
 	OpenedTime    time.Time
+	OpenAt        time.Time // zero if release of task is not scheduled
+	Hints         string
+	Files         string // paths relative to task dir separated by newline
 }
 
 func createTaskTable(db *sql.DB) (err error) {
@@ -39,6 +49,7 @@ func createTaskTable(db *sql.DB) (err error) {
 	_, err = db.Exec(`
 	CREATE TABLE IF NOT EXISTS "task" (
 		id		SERIAL PRIMARY KEY,
+		slug		TEXT UNIQUE,
 		name		TEXT NOT NULL,
 		description	TEXT NOT NULL,
 		name_en		TEXT NOT NULL,
@@ -49,15 +60,37 @@ func createTaskTable(db *sql.DB) (err error) {
 		price		INTEGER NOT NULL,
 		shared		BOOLEAN NOT NULL,
 		flag		TEXT NOT NULL,
+		flag_type	TEXT NOT NULL,
+		flag_secret	TEXT NOT NULL,
+		penalty		INTEGER NOT NULL,
+		requires	TEXT NOT NULL,
+		requires_any	BOOLEAN NOT NULL,
 		max_share_price	INTEGER NOT NULL,
 		min_share_price	INTEGER NOT NULL,
 		opened		BOOLEAN NOT NULL,
 		author		TEXT NOT NULL,
 		opened_time	TIMESTAMP with time zone,
-		force_closed	BOOLEAN NOT NULL
+		force_closed	BOOLEAN NOT NULL,
+		open_at		TIMESTAMP with time zone,
+		hints		TEXT NOT NULL,
+		files		TEXT NOT NULL
 	)`)
+	if err != nil {
+		return
+	}
 
-	return
+	return addColumns(db, "task",
+		"slug TEXT UNIQUE",
+		"flag_type TEXT NOT NULL DEFAULT ''",
+		"flag_secret TEXT NOT NULL DEFAULT ''",
+		"penalty INTEGER NOT NULL DEFAULT 0",
+		"requires TEXT NOT NULL DEFAULT ''",
+		"requires_any BOOLEAN NOT NULL DEFAULT false",
+		// zero time is stored for task without scheduled release
+		"open_at TIMESTAMP with time zone "+
+			"DEFAULT '0001-01-01 00:00:00+00'",
+		"hints TEXT NOT NULL DEFAULT ''",
+		"files TEXT NOT NULL DEFAULT ''")
 }
 
 // AddTask add task and fill id
@@ -66,9 +99,12 @@ func AddTask(db *sql.DB, t *Task) (err error) {
 	stmt, err := db.Prepare("INSERT INTO task (name, description, " +
 		"name_en, description_en, tags, " +
 		"category_id, level, price, shared, flag, max_share_price, " +
-		"min_share_price, opened, author, opened_time, force_closed) " +
-		"VALUES ($1, $2, $3, $4, $5, " +
-		"$6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING id")
+		"min_share_price, opened, author, opened_time, force_closed, " +
+		"slug, flag_type, flag_secret, penalty, requires, " +
+		"requires_any, open_at, hints, files) VALUES ($1, $2, $3, $4, " +
+		"$5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, " +
+		"NULLIF($17, ''), $18, $19, $20, $21, $22, $23, $24, $25) " +
+		"RETURNING id")
 	if err != nil {
 		return
 	}
@@ -78,7 +114,9 @@ func AddTask(db *sql.DB, t *Task) (err error) {
 	err = stmt.QueryRow(t.Name, t.Desc, t.NameEn, t.DescEn, t.Tags,
 		t.CategoryID, t.Level, t.Price, t.Shared, t.Flag,
 		t.MaxSharePrice, t.MinSharePrice,
-		t.Opened, t.Author, t.OpenedTime, t.ForceClosed).Scan(&t.ID)
+		t.Opened, t.Author, t.OpenedTime, t.ForceClosed,
+		t.Slug, t.FlagType, t.FlagSecret, t.Penalty, t.Requires,
+		t.RequiresAny, t.OpenAt, t.Hints, t.Files).Scan(&t.ID)
 	if err != nil {
 		return
 	}
@@ -86,13 +124,32 @@ func AddTask(db *sql.DB, t *Task) (err error) {
 	return
 }
 
+const taskFields = "id, name, description, name_en, " +
+	"description_en, tags, category_id, " +
+	"level, price, shared, flag, max_share_price, " +
+	"min_share_price, opened, author, opened_time, force_closed, " +
+	"COALESCE(slug, ''), flag_type, flag_secret, penalty, requires, " +
+	"requires_any, open_at, hints, files"
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask scan row selected with taskFields
+func scanTask(row scanner, t *Task) error {
+	return row.Scan(&t.ID, &t.Name, &t.Desc, &t.NameEn, &t.DescEn,
+		&t.Tags, &t.CategoryID,
+		&t.Level, &t.Price, &t.Shared, &t.Flag,
+		&t.MaxSharePrice, &t.MinSharePrice, &t.Opened,
+		&t.Author, &t.OpenedTime, &t.ForceClosed, &t.Slug,
+		&t.FlagType, &t.FlagSecret, &t.Penalty, &t.Requires,
+		&t.RequiresAny, &t.OpenAt, &t.Hints, &t.Files)
+}
+
 // GetTasks get all tasks in tasks table
 func GetTasks(db *sql.DB) (tasks []Task, err error) {
 
-	rows, err := db.Query("SELECT id, name, description, name_en, " +
-		"description_en, tags, category_id, " +
-		"level, price, shared, flag, max_share_price, " +
-		"min_share_price, opened, author, opened_time, force_closed FROM task")
+	rows, err := db.Query("SELECT " + taskFields + " FROM task")
 	if err != nil {
 		return
 	}
@@ -102,11 +159,7 @@ func GetTasks(db *sql.DB) (tasks []Task, err error) {
 	for rows.Next() {
 		var t Task
 
-		err = rows.Scan(&t.ID, &t.Name, &t.Desc, &t.NameEn, &t.DescEn,
-			&t.Tags, &t.CategoryID,
-			&t.Level, &t.Price, &t.Shared, &t.Flag,
-			&t.MaxSharePrice, &t.MinSharePrice, &t.Opened,
-			&t.Author, &t.OpenedTime, &t.ForceClosed)
+		err = scanTask(rows, &t)
 		if err != nil {
 			return
 		}
@@ -136,6 +189,24 @@ func SetOpened(db *sql.DB, taskID int, opened bool) (err error) {
 	return nil
 }
 
+// SetOpenAt schedule release of task, zero time remove schedule
+func SetOpenAt(db *sql.DB, taskID int, openAt time.Time) (err error) {
+
+	stmt, err := db.Prepare("UPDATE task SET open_at=$1 WHERE id=$2")
+	if err != nil {
+		return
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(openAt, taskID)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
 // UpdateTask update task
 func UpdateTask(db *sql.DB, t *Task) (err error) {
 
@@ -143,7 +214,10 @@ func UpdateTask(db *sql.DB, t *Task) (err error) {
 		"name_en=$3, description_en=$4, " +
 		"tags=$5, category_id=$6, level=$7, price=$8, shared=$9, flag=$10, " +
 		"max_share_price=$11, min_share_price=$12, opened=$13, " +
-		"author=$14, opened_time=$15, force_closed=$16 WHERE id=$17")
+		"author=$14, opened_time=$15, force_closed=$16, " +
+		"slug=NULLIF($17, ''), flag_type=$18, flag_secret=$19, " +
+		"penalty=$20, requires=$21, requires_any=$22, open_at=$23, " +
+		"hints=$24, files=$25 WHERE id=$26")
 	if err != nil {
 		return
 	}
@@ -153,7 +227,9 @@ func UpdateTask(db *sql.DB, t *Task) (err error) {
 	_, err = stmt.Exec(t.Name, t.Desc, t.NameEn, t.DescEn, t.Tags,
 		t.CategoryID, t.Level, t.Price,
 		t.Shared, t.Flag, t.MaxSharePrice, t.MinSharePrice, t.Opened,
-		t.Author, t.OpenedTime, t.ForceClosed, t.ID)
+		t.Author, t.OpenedTime, t.ForceClosed, t.Slug, t.FlagType,
+		t.FlagSecret, t.Penalty, t.Requires, t.RequiresAny, t.OpenAt,
+		t.Hints, t.Files, t.ID)
 	if err != nil {
 		return
 	}
@@ -161,28 +237,42 @@ func UpdateTask(db *sql.DB, t *Task) (err error) {
 	return
 }
 
-// GetTask get task by id
-func GetTask(db *sql.DB, taskID int) (t Task, err error) {
+func getTaskBy(db *sql.DB, field string, value interface{}) (t Task,
+	err error) {
 
-	stmt, err := db.Prepare("SELECT id, name, description, name_en, " +
-		"description_en, tags, category_id, " +
-		"level, price, shared, flag, max_share_price, " +
-		"min_share_price, opened, author, opened_time, force_closed " +
-		"FROM task WHERE id=$1")
+	stmt, err := db.Prepare("SELECT " + taskFields +
+		" FROM task WHERE " + field + "=$1")
 	if err != nil {
 		return
 	}
 
 	defer stmt.Close()
 
-	err = stmt.QueryRow(taskID).Scan(&t.ID, &t.Name, &t.Desc,
-		&t.NameEn, &t.DescEn, &t.Tags,
-		&t.CategoryID, &t.Level, &t.Price, &t.Shared, &t.Flag,
-		&t.MaxSharePrice, &t.MinSharePrice, &t.Opened,
-		&t.Author, &t.OpenedTime, &t.ForceClosed)
+	err = scanTask(stmt.QueryRow(value), &t)
 	if err != nil {
 		return
 	}
 
 	return
 }
+
+// GetTask get task by id
+func GetTask(db *sql.DB, taskID int) (t Task, err error) {
+	return getTaskBy(db, "id", taskID)
+}
+
+// GetTaskBySlug get task by slug
+func GetTaskBySlug(db *sql.DB, slug string) (t Task, err error) {
+	return getTaskBy(db, "slug", slug)
+}
+
+// FindTask get task by id or, if key is not a number, by slug
+func FindTask(db *sql.DB, key string) (t Task, err error) {
+
+	taskID, err := strconv.Atoi(key)
+	if err != nil {
+		return GetTaskBySlug(db, key)
+	}
+
+	return GetTask(db, taskID)
+}
@@ -206,7 +206,10 @@ func TestUpdateTask(*testing.T) {
 
 	newTaskName := "100__rand_task"
 
-	err = UpdateTask(db, &Task{ID: task.ID, Name: newTaskName})
+	files := "web1/files/a.py\nweb1/files/b.py"
+
+	err = UpdateTask(db, &Task{ID: task.ID, Name: newTaskName,
+		Hints: "look at headers", Files: files})
 	if err != nil {
 		panic(err)
 	}
@@ -219,6 +222,10 @@ func TestUpdateTask(*testing.T) {
 	if t.Name != newTaskName {
 		panic("invalid task name")
 	}
+
+	if t.Hints != "look at headers" || t.Files != files {
+		panic("invalid task hints or files")
+	}
 }
 
 func TestGetTask(*testing.T) {
@@ -248,3 +255,65 @@ func TestGetTask(*testing.T) {
 		panic("invalid task name")
 	}
 }
+
+func TestFindTask(*testing.T) {
+
+	db, err := InitDatabase(dbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	defer db.Close()
+
+	// Tasks without slug should not violate unique constraint
+	for i := 0; i < 2; i++ {
+		err = AddTask(db, &Task{})
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	task := Task{Slug: "web-1", Name: "__rand_task_2"}
+
+	err = AddTask(db, &task)
+	if err != nil {
+		panic(err)
+	}
+
+	err = AddTask(db, &Task{Slug: "web-1"})
+	if err == nil {
+		panic("duplicate slug added")
+	}
+
+	t, err := GetTaskBySlug(db, "web-1")
+	if err != nil {
+		panic(err)
+	}
+
+	if t.ID != task.ID {
+		panic("invalid task found by slug")
+	}
+
+	t, err = FindTask(db, "web-1")
+	if err != nil {
+		panic(err)
+	}
+
+	if t.Name != task.Name {
+		panic("invalid task found by slug")
+	}
+
+	t, err = FindTask(db, fmt.Sprintf("%d", task.ID))
+	if err != nil {
+		panic(err)
+	}
+
+	if t.Slug != task.Slug {
+		panic("invalid task found by id")
+	}
+
+	_, err = FindTask(db, "web-2")
+	if err == nil {
+		panic("non exist task found")
+	}
+}
@@ -9,7 +9,10 @@
 package db
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"strings"
 )
 
 // Team row
@@ -18,8 +21,14 @@ type Team struct {
 	Name  string
 	Email string
 	Desc  string
-	Token string
+	Token string // hash of access token if read from database
 	Test  bool
+
+	// Banned team can not log in and submit flags
+	Banned bool
+	// Disqualified team is hidden from scoreboard, but keep its data
+	Disqualified bool
+	Reason       string
 }
 
 func createTeamTable(db *sql.DB) (err error) {
@@ -31,26 +40,97 @@ func createTeamTable(db *sql.DB) (err error) {
 		email		TEXT NOT NULL,
 		description	TEXT NOT NULL,
 		token		TEXT NOT NULL,
-		test		BOOLEAN NOT NULL
+		test		BOOLEAN NOT NULL,
+		banned		BOOLEAN NOT NULL,
+		disqualified	BOOLEAN NOT NULL,
+		reason		TEXT NOT NULL
 	)`)
+	if err != nil {
+		return
+	}
+
+	err = addColumns(db, "team",
+		"banned BOOLEAN NOT NULL DEFAULT false",
+		"disqualified BOOLEAN NOT NULL DEFAULT false",
+		"reason TEXT NOT NULL DEFAULT ''")
+	if err != nil {
+		return
+	}
+
+	return hashTokens(db)
+}
+
+// tokenHashPrefix marks hashed access token
+const tokenHashPrefix = "sha256:"
+
+// HashToken returns hash of access token, only hashes are stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return tokenHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// hashTokens replace plaintext tokens stored by previous versions
+func hashTokens(db *sql.DB) (err error) {
+
+	rows, err := db.Query("SELECT id, token FROM team")
+	if err != nil {
+		return
+	}
+
+	plain := make(map[int]string)
+
+	for rows.Next() {
+		var id int
+		var token string
+
+		err = rows.Scan(&id, &token)
+		if err != nil {
+			rows.Close()
+			return
+		}
+
+		if !strings.HasPrefix(token, tokenHashPrefix) {
+			plain[id] = token
+		}
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	stmt, err := db.Prepare("UPDATE team SET token=$1 WHERE id=$2")
+	if err != nil {
+		return
+	}
+
+	defer stmt.Close()
+
+	for id, token := range plain {
+		_, err = stmt.Exec(HashToken(token), id)
+		if err != nil {
+			return
+		}
+	}
 
 	return
 }
 
-// AddTeam add team and fill id
+// AddTeam add team with hashed token and fill id
 func AddTeam(db *sql.DB, t *Team) (err error) {
 
 	stmt, err := db.Prepare("INSERT INTO team (name, email, " +
-		"description, token, test) " +
-		"VALUES ($1, $2, $3, $4, $5) RETURNING id")
+		"description, token, test, banned, disqualified, reason) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id")
 	if err != nil {
 		return
 	}
 
 	defer stmt.Close()
 
-	err = stmt.QueryRow(t.Name, t.Email, t.Desc, t.Token,
-		t.Test).Scan(&t.ID)
+	err = stmt.QueryRow(t.Name, t.Email, t.Desc, HashToken(t.Token),
+		t.Test, t.Banned, t.Disqualified, t.Reason).Scan(&t.ID)
 	if err != nil {
 		return
 	}
@@ -62,7 +142,7 @@ func AddTeam(db *sql.DB, t *Team) (err error) {
 func GetTeams(db *sql.DB) (teams []Team, err error) {
 
 	rows, err := db.Query("SELECT id, name, email, description, token, " +
-		"test FROM team")
+		"test, banned, disqualified, reason FROM team")
 	if err != nil {
 		return
 	}
@@ -73,7 +153,7 @@ func GetTeams(db *sql.DB) (teams []Team, err error) {
 		var t Team
 
 		err = rows.Scan(&t.ID, &t.Name, &t.Email, &t.Desc, &t.Token,
-			&t.Test)
+			&t.Test, &t.Banned, &t.Disqualified, &t.Reason)
 		if err != nil {
 			return
 		}
@@ -84,7 +164,7 @@ func GetTeams(db *sql.DB) (teams []Team, err error) {
 	return
 }
 
-// GetTeamIDByToken get team id by access token
+// GetTeamIDByToken get team id by access token, token is compared by hash
 func GetTeamIDByToken(db *sql.DB, token string) (teamID int, err error) {
 
 	stmt, err := db.Prepare("SELECT id FROM team WHERE token=$1")
@@ -94,7 +174,48 @@ func GetTeamIDByToken(db *sql.DB, token string) (teamID int, err error) {
 
 	defer stmt.Close()
 
-	err = stmt.QueryRow(token).Scan(&teamID)
+	err = stmt.QueryRow(HashToken(token)).Scan(&teamID)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// GetTeam get team by id
+func GetTeam(db *sql.DB, teamID int) (t Team, err error) {
+
+	stmt, err := db.Prepare("SELECT id, name, email, description, " +
+		"token, test, banned, disqualified, reason " +
+		"FROM team WHERE id=$1")
+	if err != nil {
+		return
+	}
+
+	defer stmt.Close()
+
+	err = stmt.QueryRow(teamID).Scan(&t.ID, &t.Name, &t.Email, &t.Desc,
+		&t.Token, &t.Test, &t.Banned, &t.Disqualified, &t.Reason)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// SetBanned set banned and disqualified state of team
+func SetBanned(db *sql.DB, teamID int, banned, disqualified bool,
+	reason string) (err error) {
+
+	stmt, err := db.Prepare("UPDATE team SET banned=$1, " +
+		"disqualified=$2, reason=$3 WHERE id=$4")
+	if err != nil {
+		return
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(banned, disqualified, reason, teamID)
 	if err != nil {
 		return
 	}
@@ -38,7 +38,7 @@ func TestAddTeam(*testing.T) {
 
 	defer db.Close()
 
-	team := Team{255, "n", "e", "d", "l", false}
+	team := Team{ID: 255, Name: "n", Email: "e", Desc: "d", Token: "l"}
 
 	err = AddTeam(db, &team)
 	if err != nil {
@@ -79,8 +79,8 @@ func TestGetTeams(*testing.T) {
 
 	for i := 0; i < nteams; i++ {
 
-		team := Team{255, fmt.Sprintf("%d", i),
-			"e", "d", "l", false}
+		team := Team{ID: 255, Name: fmt.Sprintf("%d", i),
+			Email: "e", Desc: "d", Token: "l"}
 
 		err = AddTeam(db, &team)
 		if err != nil {
@@ -149,3 +149,119 @@ func TestGetTeamIDByToken(*testing.T) {
 		panic("team id mismatch")
 	}
 }
+
+func TestHashToken(*testing.T) {
+
+	hash := HashToken("TOKEN")
+	if hash == "TOKEN" || hash != HashToken("TOKEN") ||
+		hash == HashToken("TOKEN2") {
+		panic(errors.New("Invalid token hash"))
+	}
+}
+
+// Test tokens stored in plaintext by previous versions
+func TestHashTokens(*testing.T) {
+
+	db, err := InitDatabase(dbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	defer db.Close()
+
+	token := "TOKEN_TOKEN_TOKEN"
+
+	// team table of previous version with plaintext tokens
+	_, err = db.Exec(`
+	DROP TABLE team;
+	CREATE TABLE "team" (
+		id		SERIAL PRIMARY KEY,
+		name		TEXT NOT NULL,
+		email		TEXT NOT NULL,
+		description	TEXT NOT NULL,
+		token		TEXT NOT NULL,
+		test		BOOLEAN NOT NULL
+	)`)
+	if err != nil {
+		panic(err)
+	}
+
+	var teamID int
+	err = db.QueryRow("INSERT INTO team (name, email, description, "+
+		"token, test) VALUES ('n', 'e', 'd', $1, false) "+
+		"RETURNING id", token).Scan(&teamID)
+	if err != nil {
+		panic(err)
+	}
+
+	// twice, hashed tokens must not be hashed again
+	for i := 0; i < 2; i++ {
+		err = createSchema(db)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	team, err := GetTeam(db, teamID)
+	if err != nil {
+		panic(err)
+	}
+
+	if team.Token != HashToken(token) {
+		panic(errors.New("Token is not hashed"))
+	}
+
+	id, err := GetTeamIDByToken(db, token)
+	if err != nil {
+		panic(err)
+	}
+
+	if id != teamID {
+		panic("team id mismatch")
+	}
+}
+
+func TestSetBanned(*testing.T) {
+
+	db, err := InitDatabase(dbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	defer db.Close()
+
+	team := Team{Name: "n", Email: "e", Desc: "d", Token: "l"}
+
+	err = AddTeam(db, &team)
+	if err != nil {
+		panic(err)
+	}
+
+	err = SetBanned(db, team.ID, true, true, "flag sharing")
+	if err != nil {
+		panic(err)
+	}
+
+	t, err := GetTeam(db, team.ID)
+	if err != nil {
+		panic(err)
+	}
+
+	if !t.Banned || !t.Disqualified || t.Reason != "flag sharing" {
+		panic("ban state mismatch")
+	}
+
+	err = SetBanned(db, team.ID, false, false, "")
+	if err != nil {
+		panic(err)
+	}
+
+	t, err = GetTeam(db, team.ID)
+	if err != nil {
+		panic(err)
+	}
+
+	if t.Banned || t.Disqualified || t.Reason != "" {
+		panic("ban state mismatch")
+	}
+}
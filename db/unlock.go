@@ -0,0 +1,87 @@
+/**
+ * @file unlock.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief queries for unlock table
+ */
+
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Unlock row, task opened only for one team
+type Unlock struct {
+	ID        int
+	TeamID    int
+	TaskID    int
+	Timestamp time.Time
+}
+
+func createUnlockTable(db *sql.DB) (err error) {
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS "unlock" (
+		id		SERIAL PRIMARY KEY,
+		team_id		INTEGER NOT NULL,
+		task_id		INTEGER NOT NULL,
+		timestamp	TIMESTAMP with time zone DEFAULT now(),
+		UNIQUE (team_id, task_id)
+	)`)
+
+	return
+}
+
+// AddUnlock open task for team, already opened task is ignored
+func AddUnlock(db *sql.DB, teamID, taskID int) (err error) {
+
+	stmt, err := db.Prepare("INSERT INTO unlock (team_id, task_id) " +
+		"VALUES ($1, $2) ON CONFLICT DO NOTHING")
+	if err != nil {
+		return
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(teamID, taskID)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// GetUnlocks get all tasks opened for team
+func GetUnlocks(db *sql.DB, teamID int) (unlocks []Unlock, err error) {
+
+	stmt, err := db.Prepare("SELECT id, team_id, task_id, timestamp " +
+		"FROM unlock WHERE team_id=$1")
+	if err != nil {
+		return
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.Query(teamID)
+	if err != nil {
+		return
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var u Unlock
+
+		err = rows.Scan(&u.ID, &u.TeamID, &u.TaskID, &u.Timestamp)
+		if err != nil {
+			return
+		}
+
+		unlocks = append(unlocks, u)
+	}
+
+	return
+}
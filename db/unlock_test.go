@@ -0,0 +1,49 @@
+/**
+ * @file unlock_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief test work with unlock table
+ */
+
+package db
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnlock(*testing.T) {
+
+	db, err := InitDatabase(dbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	defer db.Close()
+
+	for i := 0; i < 2; i++ { // second unlock must be ignored
+		err = AddUnlock(db, 1, 2)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	unlocks, err := GetUnlocks(db, 1)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(unlocks) != 1 || unlocks[0].TaskID != 2 {
+		panic(errors.New("Get invalid unlocks"))
+	}
+
+	unlocks, err = GetUnlocks(db, 2)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(unlocks) != 0 {
+		panic(errors.New("Get unlocks of other team"))
+	}
+}
@@ -0,0 +1,175 @@
+/**
+ * @file audit.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief cheating detection
+ *
+ * Contain functions for find suspicious activity of teams
+ */
+
+package game
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/jollheef/henhouse/db"
+)
+
+// SharedAddr provide teams logged in from same address
+type SharedAddr struct {
+	Addr    string
+	TeamIDs []int
+}
+
+// SharedWrongFlag provide teams submitted same wrong flag for task
+type SharedWrongFlag struct {
+	TaskID  int
+	Flag    string
+	TeamIDs []int
+}
+
+// CloseSolve provide two teams solved task with small interval
+type CloseSolve struct {
+	TaskID       int
+	FirstTeamID  int
+	SecondTeamID int
+	Interval     time.Duration
+}
+
+// AuditReport provide all found suspicious activity
+type AuditReport struct {
+	SharedAddrs      []SharedAddr
+	SharedWrongFlags []SharedWrongFlag
+	CloseSolves      []CloseSolve
+	Alerts           []db.Alert // submissions of other teams flags
+}
+
+// uniqueTeams add team id to set, returns new set
+func uniqueTeams(teamIDs []int, teamID int) []int {
+	for _, id := range teamIDs {
+		if id == teamID {
+			return teamIDs
+		}
+	}
+	return append(teamIDs, teamID)
+}
+
+func sharedAddrs(sessions []db.Session) (shared []SharedAddr) {
+
+	teams := make(map[string][]int)
+	for _, s := range sessions {
+		if s.Addr == "" {
+			continue
+		}
+		teams[s.Addr] = uniqueTeams(teams[s.Addr], s.TeamID)
+	}
+
+	for addr, teamIDs := range teams {
+		if len(teamIDs) > 1 {
+			sort.Ints(teamIDs)
+			shared = append(shared, SharedAddr{addr, teamIDs})
+		}
+	}
+
+	sort.Slice(shared, func(i, j int) bool {
+		return shared[i].Addr < shared[j].Addr
+	})
+
+	return
+}
+
+func sharedWrongFlags(flags []db.Flag) (shared []SharedWrongFlag) {
+
+	type key struct {
+		taskID int
+		flag   string
+	}
+
+	teams := make(map[key][]int)
+	for _, f := range flags {
+		if f.Solved {
+			continue
+		}
+		k := key{f.TaskID, f.Flag}
+		teams[k] = uniqueTeams(teams[k], f.TeamID)
+	}
+
+	for k, teamIDs := range teams {
+		if len(teamIDs) > 1 {
+			sort.Ints(teamIDs)
+			shared = append(shared,
+				SharedWrongFlag{k.taskID, k.flag, teamIDs})
+		}
+	}
+
+	sort.Slice(shared, func(i, j int) bool {
+		if shared[i].TaskID == shared[j].TaskID {
+			return shared[i].Flag < shared[j].Flag
+		}
+		return shared[i].TaskID < shared[j].TaskID
+	})
+
+	return
+}
+
+func closeSolves(flags []db.Flag, window time.Duration) (found []CloseSolve) {
+
+	var solves []db.Flag
+	for _, f := range flags {
+		if f.Solved {
+			solves = append(solves, f)
+		}
+	}
+
+	sort.Slice(solves, func(i, j int) bool {
+		if solves[i].TaskID == solves[j].TaskID {
+			return solves[i].Timestamp.Before(solves[j].Timestamp)
+		}
+		return solves[i].TaskID < solves[j].TaskID
+	})
+
+	for i := 1; i < len(solves); i++ {
+		prev, cur := solves[i-1], solves[i]
+		if prev.TaskID != cur.TaskID {
+			continue
+		}
+
+		interval := cur.Timestamp.Sub(prev.Timestamp)
+		if interval <= window {
+			found = append(found, CloseSolve{cur.TaskID,
+				prev.TeamID, cur.TeamID, interval})
+		}
+	}
+
+	return
+}
+
+// Audit find teams with shared addresses, same wrong flags, solves of same
+// task in window and submissions of dynamic flags of other teams
+func Audit(database *sql.DB, window time.Duration) (report AuditReport,
+	err error) {
+
+	sessions, err := db.GetSessions(database)
+	if err != nil {
+		return
+	}
+
+	flags, err := db.GetFlags(database)
+	if err != nil {
+		return
+	}
+
+	report.Alerts, err = db.GetAlerts(database)
+	if err != nil {
+		return
+	}
+
+	report.SharedAddrs = sharedAddrs(sessions)
+	report.SharedWrongFlags = sharedWrongFlags(flags)
+	report.CloseSolves = closeSolves(flags, window)
+
+	return
+}
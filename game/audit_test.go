@@ -0,0 +1,76 @@
+/**
+ * @file audit_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief test cheating detection
+ */
+
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jollheef/henhouse/db"
+)
+
+func TestSharedAddrs(*testing.T) {
+
+	sessions := []db.Session{
+		{TeamID: 1, Addr: "10.0.0.1"},
+		{TeamID: 1, Addr: "10.0.0.1"},
+		{TeamID: 2, Addr: "10.0.0.2"},
+		{TeamID: 3, Addr: "10.0.0.1"},
+	}
+
+	shared := sharedAddrs(sessions)
+
+	if len(shared) != 1 || shared[0].Addr != "10.0.0.1" ||
+		len(shared[0].TeamIDs) != 2 {
+		panic("invalid shared addresses")
+	}
+}
+
+func TestSharedWrongFlags(*testing.T) {
+
+	flags := []db.Flag{
+		{TeamID: 1, TaskID: 1, Flag: "wrong"},
+		{TeamID: 2, TaskID: 1, Flag: "wrong"},
+		{TeamID: 3, TaskID: 2, Flag: "wrong"},
+		{TeamID: 1, TaskID: 3, Flag: "valid", Solved: true},
+		{TeamID: 2, TaskID: 3, Flag: "valid", Solved: true},
+	}
+
+	shared := sharedWrongFlags(flags)
+
+	if len(shared) != 1 || shared[0].TaskID != 1 ||
+		len(shared[0].TeamIDs) != 2 {
+		panic("invalid shared wrong flags")
+	}
+}
+
+func TestCloseSolves(*testing.T) {
+
+	now := time.Now()
+
+	flags := []db.Flag{
+		{TeamID: 1, TaskID: 1, Solved: true, Timestamp: now},
+		{TeamID: 2, TaskID: 1, Solved: true,
+			Timestamp: now.Add(10 * time.Second)},
+		{TeamID: 3, TaskID: 1, Solved: true,
+			Timestamp: now.Add(time.Hour)},
+		{TeamID: 4, TaskID: 1, Solved: false,
+			Timestamp: now.Add(time.Hour + time.Second)},
+		{TeamID: 1, TaskID: 2, Solved: true,
+			Timestamp: now.Add(time.Hour + time.Second)},
+	}
+
+	found := closeSolves(flags, 30*time.Second)
+
+	if len(found) != 1 || found[0].FirstTeamID != 1 ||
+		found[0].SecondTeamID != 2 ||
+		found[0].Interval != 10*time.Second {
+		panic("invalid close solves")
+	}
+}
@@ -0,0 +1,134 @@
+/**
+ * @file flag.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief flag matching
+ *
+ * Contain flag matchers and cache of compiled flags
+ */
+
+package game
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jollheef/henhouse/db"
+)
+
+// Flag types
+const (
+	// FlagRegex flag is a regular expression for whole flag (default)
+	FlagRegex = "regex"
+	// FlagExact flag must be equal to submitted
+	FlagExact = "exact"
+	// FlagCaseInsensitive flag must be equal to submitted ignoring case
+	FlagCaseInsensitive = "icase"
+	// FlagDynamic flag is a format with %s, which replaced by hmac of
+	// team id with flag secret of task, so each team has own flag
+	FlagDynamic = "dynamic"
+)
+
+// DynamicFlag returns flag of team for task with dynamic flag
+func DynamicFlag(format, secret string, teamID int) string {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.Itoa(teamID)))
+
+	token := hex.EncodeToString(mac.Sum(nil))[:32]
+
+	return strings.Replace(format, "%s", token, 1)
+}
+
+type flagMatcher struct {
+	flagType string
+	source   string // flag as it stored in task
+	flag     string
+	secret   string
+	re       *regexp.Regexp
+}
+
+func newFlagMatcher(flagType, flag, secret string) (m flagMatcher,
+	err error) {
+
+	m.flagType = flagType
+	m.source = flag
+	m.flag = flag
+	m.secret = secret
+
+	switch flagType {
+	case "", FlagRegex:
+		m.re, err = regexp.Compile("^(" + flag + ")$")
+	case FlagExact:
+	case FlagCaseInsensitive:
+		m.flag = strings.ToLower(flag)
+	case FlagDynamic:
+		if strings.Count(flag, "%s") != 1 {
+			err = errors.New("Dynamic flag format must contain " +
+				"one %s")
+		} else if secret == "" {
+			err = errors.New("Dynamic flag without secret")
+		}
+	default:
+		err = errors.New("Unknown flag type " + flagType)
+	}
+
+	return
+}
+
+func constantTimeEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func (m flagMatcher) match(teamID int, flag string) bool {
+
+	switch m.flagType {
+	case FlagDynamic:
+		return constantTimeEqual(DynamicFlag(m.flag, m.secret, teamID),
+			flag)
+	case FlagExact:
+		return constantTimeEqual(m.flag, flag)
+	case FlagCaseInsensitive:
+		return constantTimeEqual(m.flag, strings.ToLower(flag))
+	}
+
+	return m.re.MatchString(flag)
+}
+
+// flagCache keep compiled flags, flag recompiled only if task changed
+type flagCache struct {
+	lock     sync.Mutex
+	matchers map[int]flagMatcher
+}
+
+func newFlagCache() *flagCache {
+	return &flagCache{matchers: make(map[int]flagMatcher)}
+}
+
+func (c *flagCache) get(task db.Task) (m flagMatcher, err error) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	m, ok := c.matchers[task.ID]
+	if ok && m.flagType == task.FlagType && m.source == task.Flag &&
+		m.secret == task.FlagSecret {
+		return
+	}
+
+	m, err = newFlagMatcher(task.FlagType, task.Flag, task.FlagSecret)
+	if err != nil {
+		return
+	}
+
+	c.matchers[task.ID] = m
+	return
+}
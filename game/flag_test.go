@@ -0,0 +1,118 @@
+/**
+ * @file flag_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief test flag matching
+ */
+
+package game
+
+import (
+	"testing"
+
+	"github.com/jollheef/henhouse/db"
+)
+
+func testFlagMatch(flagType, flag, submitted string, valid bool) {
+
+	m, err := newFlagMatcher(flagType, flag, "")
+	if err != nil {
+		panic(err)
+	}
+
+	if m.match(1, submitted) != valid {
+		panic("invalid match of " + submitted + " with " + flag)
+	}
+}
+
+func TestFlagMatcher(*testing.T) {
+
+	testFlagMatch("", "flag_[0-9]+", "flag_123", true)
+	testFlagMatch(FlagRegex, "flag_[0-9]+", "flag_abc", false)
+	testFlagMatch(FlagRegex, "flag", "flag_and_more", false)
+
+	testFlagMatch(FlagExact, "fl.g+", "fl.g+", true)
+	testFlagMatch(FlagExact, "fl.g+", "flagg", false)
+	testFlagMatch(FlagExact, "Flag", "flag", false)
+
+	testFlagMatch(FlagCaseInsensitive, "Flag{ABC}", "flag{abc}", true)
+	testFlagMatch(FlagCaseInsensitive, "Flag{ABC}", "flag{abd}", false)
+
+	_, err := newFlagMatcher(FlagRegex, "flag(", "")
+	if err == nil {
+		panic("invalid regex compiled")
+	}
+
+	_, err = newFlagMatcher("fuzzy", "flag", "")
+	if err == nil {
+		panic("unknown flag type accepted")
+	}
+}
+
+func TestFlagCache(*testing.T) {
+
+	c := newFlagCache()
+
+	task := db.Task{ID: 1, Flag: "flag.", FlagType: FlagRegex}
+
+	m, err := c.get(task)
+	if err != nil {
+		panic(err)
+	}
+
+	if !m.match(1, "flagX") {
+		panic("regex flag not matched")
+	}
+
+	task.FlagType = FlagExact
+
+	m, err = c.get(task)
+	if err != nil {
+		panic(err)
+	}
+
+	if m.match(1, "flagX") {
+		panic("flag not recompiled after change of type")
+	}
+}
+
+func TestDynamicFlag(*testing.T) {
+
+	format := "flag{%s}"
+	secret := "secret"
+
+	flag1 := DynamicFlag(format, secret, 1)
+	flag2 := DynamicFlag(format, secret, 2)
+
+	if flag1 == flag2 {
+		panic("same dynamic flag for different teams")
+	}
+
+	if flag1 != DynamicFlag(format, secret, 1) {
+		panic("dynamic flag is not deterministic")
+	}
+
+	if flag1 == DynamicFlag(format, "other", 1) {
+		panic("dynamic flag does not depend on secret")
+	}
+
+	m, err := newFlagMatcher(FlagDynamic, format, secret)
+	if err != nil {
+		panic(err)
+	}
+
+	if !m.match(1, flag1) || m.match(2, flag1) {
+		panic("invalid match of dynamic flag")
+	}
+
+	_, err = newFlagMatcher(FlagDynamic, "flag", secret)
+	if err == nil {
+		panic("dynamic flag without %s accepted")
+	}
+
+	_, err = newFlagMatcher(FlagDynamic, format, "")
+	if err == nil {
+		panic("dynamic flag without secret accepted")
+	}
+}
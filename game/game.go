@@ -11,35 +11,52 @@
 package game
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"log/slog"
 	"math"
-	"regexp"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jollheef/henhouse/db"
+	"github.com/jollheef/henhouse/logging"
+	"github.com/jollheef/henhouse/metrics"
 )
 
-// Game struct
-type Game struct {
-	db              *sql.DB
-	Start           time.Time
-	End             time.Time
+// Settings of game can be changed while game is running
+type Settings struct {
 	OpenTimeout     time.Duration // after solve task
 	AutoOpen        bool
 	AutoOpenTimeout time.Duration // if task does not solved
-	scoreboardLock  *sync.Mutex
+	Penalty         int           // for wrong flag if task has no own
+	PenaltyCap      int           // max penalty for one task, 0 - no limit
 	TaskPrice       struct {
 		TeamsBase              float64
 		P500, P400, P300, P200 float64
 	}
 }
 
+// Game struct
+type Game struct {
+	db             *sql.DB
+	PerTeam        bool // open next task only for solved team
+	scoreboardLock *sync.Mutex
+	flags          *flagCache
+	settings       *atomic.Pointer[Settings]
+}
+
+var recalcDuration = metrics.NewHistogram(
+	"henhouse_scoreboard_recalc_seconds",
+	"Duration of scoreboard recalculation.", metrics.DefaultBuckets)
+
 // TaskInfo provide information about task
 type TaskInfo struct {
 	ID          int
+	Slug        string
 	Name        string
 	Desc        string
 	NameEn      string
@@ -52,6 +69,11 @@ type TaskInfo struct {
 	ForceClosed bool
 	SolvedBy    []int
 	OpenedTime  time.Time
+	Requires    []string // slugs of required tasks
+	RequiresAny bool
+	OpenAt      time.Time // scheduled release, zero if not scheduled
+	Hints       string
+	Files       []string // paths relative to task dir
 }
 
 // CategoryInfo provide information about categories and tasks
@@ -106,7 +128,7 @@ func CalcTeamsBase(database *sql.DB) (z float64, err error) {
 	for _, t := range teams {
 		solvedCount := 0
 		for _, f := range flags {
-			if f.TeamID == t.ID {
+			if f.TeamID == t.ID && f.Solved {
 				solvedCount++
 			}
 		}
@@ -136,18 +158,29 @@ func NewGame(database *sql.DB, start, end time.Time,
 	teamBase float64) (g Game, err error) {
 
 	g.db = database
-	g.Start = start
-	g.End = end
 
-	// Default values
-	g.TaskPrice.P200 = 0.50
-	g.TaskPrice.P300 = 0.30
-	g.TaskPrice.P400 = 0.15
-	g.TaskPrice.P500 = 0.10
+	state := db.Game{Start: start, End: end}
+
+	err = db.InitGame(database, &state)
+	if err != nil {
+		return
+	}
+
+	if !state.Start.Equal(start) || !state.End.Equal(end) {
+		slog.Info("Use game time from database", "start", state.Start,
+			"end", state.End)
+	}
 
 	g.scoreboardLock = &sync.Mutex{}
+	g.flags = newFlagCache()
+	g.settings = newSettings()
 
-	g.TaskPrice.TeamsBase = teamBase
+	g.setTeamsBase(teamBase)
+
+	err = g.CompileFlags()
+	if err != nil {
+		return
+	}
 
 	_, err = g.Scoreboard()
 	if err != nil {
@@ -160,39 +193,125 @@ func NewGame(database *sql.DB, start, end time.Time,
 	return
 }
 
+// CompileFlags compile flags of all tasks, invalid flags are only logged
+// because they should not prevent other tasks from solving
+func (g Game) CompileFlags() (err error) {
+
+	tasks, err := db.GetTasks(g.db)
+	if err != nil {
+		return
+	}
+
+	for _, task := range tasks {
+		// error is not logged because it may contain the flag
+		if _, err := g.flags.get(task); err != nil {
+			slog.Error("Invalid flag of task", "task", task.ID,
+				"slug", task.Slug)
+		}
+	}
+
+	return
+}
+
+// newSettings returns settings with default values
+func newSettings() (settings *atomic.Pointer[Settings]) {
+	var s Settings
+	s.TaskPrice.P200 = 0.50
+	s.TaskPrice.P300 = 0.30
+	s.TaskPrice.P400 = 0.15
+	s.TaskPrice.P500 = 0.10
+
+	settings = &atomic.Pointer[Settings]{}
+	settings.Store(&s)
+	return
+}
+
+// Settings returns current settings of game
+func (g Game) Settings() Settings {
+	return *g.settings.Load()
+}
+
+// UpdateSettings change settings of game, it is safe to call while game
+// is running
+func (g Game) UpdateSettings(update func(s *Settings)) {
+	for {
+		old := g.settings.Load()
+		s := *old
+		update(&s)
+		if g.settings.CompareAndSwap(old, &s) {
+			return
+		}
+	}
+}
+
 // SetTaskPrice convert and set price of tasks
 func (g *Game) SetTaskPrice(p500, p400, p300, p200 int) {
-	g.TaskPrice.P200 = float64(p200) / 100
-	g.TaskPrice.P300 = float64(p300) / 100
-	g.TaskPrice.P400 = float64(p400) / 100
-	g.TaskPrice.P500 = float64(p500) / 100
+	g.UpdateSettings(func(s *Settings) {
+		s.TaskPrice.P200 = float64(p200) / 100
+		s.TaskPrice.P300 = float64(p300) / 100
+		s.TaskPrice.P400 = float64(p400) / 100
+		s.TaskPrice.P500 = float64(p500) / 100
+	})
 }
 
 // SetTeamsBase force set amount of teams for calc price task
 func (g *Game) SetTeamsBase(teams int) {
-	g.TaskPrice.TeamsBase = float64(teams)
+	g.setTeamsBase(float64(teams))
+}
+
+func (g *Game) setTeamsBase(teams float64) {
+	g.UpdateSettings(func(s *Settings) {
+		s.TaskPrice.TeamsBase = teams
+	})
 }
 
-// TeamsBaseUpdater auto update TeamsBase
-func (g *Game) TeamsBaseUpdater(database *sql.DB, updateTimeout time.Duration) {
+// sleep returns false if context canceled before timeout
+func sleep(ctx context.Context, timeout time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(timeout):
+		return true
+	}
+}
+
+// TeamsBaseUpdater auto update TeamsBase until cancel of context
+func (g *Game) TeamsBaseUpdater(ctx context.Context, database *sql.DB,
+	updateTimeout time.Duration) {
+
 	for {
 		z, err := CalcTeamsBase(database)
 		if err != nil {
 			return
 		}
 
-		log.Println("Set teams base to", z)
-		g.TaskPrice.TeamsBase = z
+		slog.Info("Set teams base", "teams_base", z)
+		g.setTeamsBase(z)
 
-		time.Sleep(updateTimeout)
+		if !sleep(ctx, updateTimeout) {
+			return
+		}
 	}
 }
 
-// Run open first level tasks and start auto open routine
-func (g Game) Run() (err error) {
+// Run open first level tasks and start auto open routine, settings of
+// game can be changed while running, routine is stopped by context
+func (g *Game) Run(ctx context.Context) (err error) {
+
+	for {
+		var state db.Game
+		state, err = g.State()
+		if err != nil {
+			return
+		}
+
+		if time.Now().After(state.Start) && !state.Paused {
+			break
+		}
 
-	for time.Now().Before(g.Start) {
-		time.Sleep(time.Second)
+		if !sleep(ctx, time.Second) {
+			return
+		}
 	}
 
 	cats, err := g.Tasks()
@@ -200,13 +319,19 @@ func (g Game) Run() (err error) {
 		return
 	}
 
+	now := time.Now()
+
 	for _, c := range cats {
 		for _, t := range c.TasksInfo {
-			if t.ForceClosed {
+			if t.ForceClosed || len(t.Requires) != 0 {
 				continue
 			}
 
-			log.Println("Open task", t.Name, t.Level)
+			if Scheduled(t.OpenAt, now) {
+				break // first task will be opened by schedule
+			}
+
+			slog.Info("Open task", "task", t.Name, "level", t.Level)
 			err = db.SetOpened(g.db, t.ID, true)
 			if err != nil {
 				return
@@ -216,16 +341,16 @@ func (g Game) Run() (err error) {
 		}
 	}
 
-	if !g.AutoOpen {
+	err = g.openUnlockedTasks(0)
+	if err != nil {
 		return
 	}
 
 	go func() {
-		for {
-			time.Sleep(time.Second)
-			err = g.autoOpenTasks()
+		for sleep(ctx, time.Second) {
+			err := g.autoOpenTasks()
 			if err != nil {
-				log.Println("Auto open tasks fail:", err)
+				slog.Error("Auto open tasks fail", "err", err)
 			}
 		}
 	}()
@@ -233,8 +358,49 @@ func (g Game) Run() (err error) {
 	return
 }
 
+// Scheduled returns true if release of task is scheduled after now
+func Scheduled(openAt, now time.Time) bool {
+	return !openAt.IsZero() && now.Before(openAt)
+}
+
+// openScheduledTasks open tasks with release time in past, task closed
+// after release is not reopened
+func (g Game) openScheduledTasks() (err error) {
+
+	now := time.Now()
+
+	tasks, err := db.GetTasks(g.db)
+	if err != nil {
+		return
+	}
+
+	for _, task := range tasks {
+		if task.OpenAt.IsZero() || task.Opened || task.ForceClosed ||
+			now.Before(task.OpenAt) ||
+			task.OpenedTime.After(task.OpenAt) {
+			continue
+		}
+
+		slog.Info("Open scheduled task", "task", task.Name,
+			"open_at", task.OpenAt)
+		err = db.SetOpened(g.db, task.ID, true)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 func (g Game) autoOpenTasks() (err error) {
 
+	settings := g.Settings()
+
+	err = g.openScheduledTasks()
+	if err != nil || !settings.AutoOpen {
+		return
+	}
+
 	now := time.Now()
 
 	cats, err := g.Tasks()
@@ -244,14 +410,23 @@ func (g Game) autoOpenTasks() (err error) {
 
 	for _, c := range cats {
 		prev := TaskInfo{Opened: true}
-		for i, t := range c.TasksInfo {
-			if i == 0 || t.Opened || !prev.Opened || t.ForceClosed {
+		first := true
+		for _, t := range c.TasksInfo {
+			if len(t.Requires) != 0 {
+				// not a part of level chain
+				continue
+			}
+
+			if first || t.Opened || !prev.Opened || t.ForceClosed ||
+				Scheduled(t.OpenAt, now) {
+				first = false
 				prev = t
 				continue
 			}
 
-			if now.After(prev.OpenedTime.Add(g.AutoOpenTimeout)) {
-				log.Println("Open task", t.Name, t.Level)
+			timeout := settings.AutoOpenTimeout
+			if now.After(prev.OpenedTime.Add(timeout)) {
+				slog.Info("Open task", "task", t.Name, "level", t.Level)
 				err = db.SetOpened(g.db, t.ID, true)
 				if err != nil {
 					return
@@ -270,15 +445,17 @@ func (g *Game) taskPrice(database *sql.DB, taskID int) (price int, err error) {
 
 	count, err := db.GetSolvedCount(database, taskID)
 
-	fprice := float64(count) / g.TaskPrice.TeamsBase
+	p := g.Settings().TaskPrice
 
-	if fprice <= g.TaskPrice.P500 {
+	fprice := float64(count) / p.TeamsBase
+
+	if fprice <= p.P500 {
 		price = 500
-	} else if fprice <= g.TaskPrice.P400 {
+	} else if fprice <= p.P400 {
 		price = 400
-	} else if fprice <= g.TaskPrice.P300 {
+	} else if fprice <= p.P300 {
 		price = 300
-	} else if fprice <= g.TaskPrice.P200 {
+	} else if fprice <= p.P200 {
 		price = 200
 	} else {
 		price = 100
@@ -289,6 +466,51 @@ func (g *Game) taskPrice(database *sql.DB, taskID int) (price int, err error) {
 
 // Tasks returns categories with tasks
 func (g Game) Tasks() (cats []CategoryInfo, err error) {
+	return g.tasks(nil)
+}
+
+// TeamTasks returns categories with tasks opened for all or for team
+func (g Game) TeamTasks(teamID int) (cats []CategoryInfo, err error) {
+
+	if !g.PerTeam {
+		return g.Tasks()
+	}
+
+	unlocked, err := g.unlocked(teamID)
+	if err != nil {
+		return
+	}
+
+	return g.tasks(unlocked)
+}
+
+// unlocked returns set of ids of tasks opened only for team
+func (g Game) unlocked(teamID int) (unlocked map[int]bool, err error) {
+
+	unlocks, err := db.GetUnlocks(g.db, teamID)
+	if err != nil {
+		return
+	}
+
+	unlocked = make(map[int]bool)
+	for _, u := range unlocks {
+		unlocked[u.TaskID] = true
+	}
+
+	return
+}
+
+// TaskFiles returns paths of files of task relative to task dir
+func TaskFiles(task db.Task) (files []string) {
+	for _, file := range strings.Split(task.Files, "\n") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return
+}
+
+func (g Game) tasks(unlocked map[int]bool) (cats []CategoryInfo, err error) {
 
 	tasks, err := db.GetTasks(g.db)
 	if err != nil {
@@ -320,12 +542,19 @@ func (g Game) Tasks() (cats []CategoryInfo, err error) {
 					return
 				}
 
+				if unlocked[task.ID] {
+					task.Opened = true
+				}
+
 				if !task.Opened {
 					task.Desc = ""
+					task.Hints = ""
+					task.Files = ""
 				}
 
 				tInfo := TaskInfo{
 					ID:          task.ID,
+					Slug:        task.Slug,
 					Name:        task.Name,
 					Desc:        task.Desc,
 					NameEn:      task.NameEn,
@@ -338,6 +567,11 @@ func (g Game) Tasks() (cats []CategoryInfo, err error) {
 					Level:       task.Level,
 					ForceClosed: task.ForceClosed,
 					OpenedTime:  task.OpenedTime,
+					Requires:    RequiredSlugs(task),
+					RequiresAny: task.RequiresAny,
+					OpenAt:      task.OpenAt,
+					Hints:       task.Hints,
+					Files:       TaskFiles(task),
 				}
 
 				cat.TasksInfo = append(cat.TasksInfo, tInfo)
@@ -352,41 +586,99 @@ func (g Game) Tasks() (cats []CategoryInfo, err error) {
 	return
 }
 
+// TaskID returns id of task by id or slug
+func (g Game) TaskID(key string) (taskID int, err error) {
+
+	task, err := db.FindTask(g.db, key)
+	if err != nil {
+		return
+	}
+
+	taskID = task.ID
+	return
+}
+
 // LastAccept returns last time of accepted flag for team
 func LastAccept(teamID int, flags []db.Flag) int64 {
 	timestamp := time.Unix(0, 0)
 	for _, f := range flags {
-		if f.TeamID == teamID && f.Timestamp.After(timestamp) {
+		if f.TeamID == teamID && f.Solved &&
+			f.Timestamp.After(timestamp) {
 			timestamp = f.Timestamp
 		}
 	}
 	return timestamp.Unix()
 }
 
-// Scoreboard returns sorted scoreboard
+// Scoreboard returns sorted public scoreboard, solves after freeze are
+// hidden
 func (g Game) Scoreboard() (scores []TeamScoreInfo, err error) {
 
 	g.scoreboardLock.Lock()
 	defer g.scoreboardLock.Unlock()
 
-	teams, err := db.GetTeams(g.db)
+	state, err := g.State()
 	if err != nil {
 		return
 	}
 
-	flags, err := db.GetFlags(g.db)
+	return PublicScoreboard(g.db, state)
+}
+
+// RealScoreboard returns sorted scoreboard with solves after freeze
+func (g Game) RealScoreboard() (scores []TeamScoreInfo, err error) {
+
+	g.scoreboardLock.Lock()
+	defer g.scoreboardLock.Unlock()
+
+	return scoreboardBefore(g.db, time.Time{})
+}
+
+// PublicScoreboard returns sorted scoreboard as seen by public in game state
+func PublicScoreboard(database *sql.DB, state db.Game) (
+	scores []TeamScoreInfo, err error) {
+
+	var before time.Time
+	if Frozen(state, time.Now()) {
+		before = state.Freeze
+	}
+
+	return scoreboardBefore(database, before)
+}
+
+// scoreboardBefore returns scoreboard at time, last scores if time is zero
+func scoreboardBefore(database *sql.DB, before time.Time) (
+	scores []TeamScoreInfo, err error) {
+
+	teams, err := db.GetTeams(database)
+	if err != nil {
+		return
+	}
+
+	flags, err := db.GetFlags(database)
 	if err != nil {
 		return
 	}
 
+	if !before.IsZero() {
+		flags = flagsBefore(flags, before)
+	}
+
 	for _, team := range teams {
 
-		if team.Test {
+		if team.Test || team.Disqualified {
 			continue
 		}
 
 		var s db.Score
-		s, err = db.GetLastScore(g.db, team.ID)
+		if before.IsZero() {
+			s, err = db.GetLastScore(database, team.ID)
+		} else {
+			s, err = db.GetLastScoreBefore(database, team.ID, before)
+			if err == sql.ErrNoRows {
+				err = nil // no score before freeze
+			}
+		}
 		if err != nil {
 			return
 		}
@@ -405,12 +697,88 @@ func (g Game) Scoreboard() (scores []TeamScoreInfo, err error) {
 	return
 }
 
+// AwardsPoints returns sum of manual adjustments of team
+func AwardsPoints(teamID int, awards []db.Award) (points int) {
+	for _, a := range awards {
+		if a.TeamID == teamID {
+			points += a.Points
+		}
+	}
+	return
+}
+
+// WrongFlagPenalty returns penalty for wrong flags of one task
+func WrongFlagPenalty(penalty, penaltyCap, wrong int) (points int) {
+
+	points = penalty * wrong
+
+	if penaltyCap != 0 && points > penaltyCap {
+		points = penaltyCap
+	}
+
+	return
+}
+
+// taskPenalty counts wrong flags of team submitted before solve of task
+func (g Game) taskPenalty(task db.Task, teamID int,
+	flags []db.Flag) (penalty, wrong int) {
+
+	var solvedAt time.Time
+	for _, f := range flags {
+		if f.TeamID == teamID && f.TaskID == task.ID && f.Solved &&
+			(solvedAt.IsZero() || f.Timestamp.Before(solvedAt)) {
+			solvedAt = f.Timestamp
+		}
+	}
+
+	for _, f := range flags {
+		if f.TeamID == teamID && f.TaskID == task.ID && !f.Solved &&
+			(solvedAt.IsZero() || f.Timestamp.Before(solvedAt)) {
+			wrong++
+		}
+	}
+
+	settings := g.Settings()
+
+	points := task.Penalty
+	if points == 0 {
+		points = settings.Penalty
+	} else if points < 0 {
+		points = 0 // task opt out of global penalty
+	}
+
+	penalty = WrongFlagPenalty(points, settings.PenaltyCap, wrong)
+	return
+}
+
+// TaskPenalty returns penalty of team for wrong flags of task
+func (g Game) TaskPenalty(teamID, taskID int) (penalty, wrong int,
+	err error) {
+
+	task, err := db.GetTask(g.db, taskID)
+	if err != nil {
+		return
+	}
+
+	flags, err := db.GetFlags(g.db)
+	if err != nil {
+		return
+	}
+
+	penalty, wrong = g.taskPenalty(task, teamID, flags)
+	return
+}
+
 // RecalcScoreboard update scoreboard
 func (g Game) RecalcScoreboard() (err error) {
 
 	g.scoreboardLock.Lock()
 	defer g.scoreboardLock.Unlock()
 
+	defer func(start time.Time) {
+		recalcDuration.Observe(time.Since(start).Seconds())
+	}(time.Now())
+
 	teams, err := db.GetTeams(g.db)
 	if err != nil {
 		return
@@ -421,13 +789,23 @@ func (g Game) RecalcScoreboard() (err error) {
 		return
 	}
 
+	awards, err := db.GetAwards(g.db)
+	if err != nil {
+		return
+	}
+
+	flags, err := db.GetFlags(g.db)
+	if err != nil {
+		return
+	}
+
 	for _, team := range teams {
 
 		if team.Test {
 			continue
 		}
 
-		score := 0
+		score := AwardsPoints(team.ID, awards)
 
 		for _, task := range tasks {
 
@@ -446,6 +824,9 @@ func (g Game) RecalcScoreboard() (err error) {
 			if solved {
 				score += price
 			}
+
+			penalty, _ := g.taskPenalty(task, team.ID, flags)
+			score -= penalty
 		}
 
 		err = db.AddScore(g.db, &db.Score{TeamID: team.ID, Score: score})
@@ -457,24 +838,40 @@ func (g Game) RecalcScoreboard() (err error) {
 	return
 }
 
-// OpenNextTask open next task by level
-func (g Game) OpenNextTask(t db.Task) (err error) {
+// openTask open task for all or, in per team mode, only for team
+func (g Game) openTask(teamID int, task db.Task) (err error) {
 
-	time.Sleep(g.OpenTimeout)
+	if g.PerTeam && teamID != 0 {
+		slog.Info("Open task", "task", task.Name, "level", task.Level,
+			"team", teamID)
+		return db.AddUnlock(g.db, teamID, task.ID)
+	}
+
+	slog.Info("Open task", "task", task.Name, "level", task.Level)
+	return db.SetOpened(g.db, task.ID, true)
+}
+
+// OpenNextTask open next task by level after team solve task
+func (g Game) OpenNextTask(teamID int, t db.Task) (err error) {
+
+	time.Sleep(g.Settings().OpenTimeout)
 
 	tasks, err := db.GetTasks(g.db)
 	if err != nil {
 		return
 	}
 
+	now := time.Now()
+
 	for _, task := range tasks {
 		// If same category and next level
-		if t.CategoryID == task.CategoryID && t.Level+1 == task.Level {
-			// If not already opened
-			if !task.Opened && !task.ForceClosed {
+		if t.CategoryID == task.CategoryID && t.Level+1 == task.Level &&
+			task.Requires == "" {
+			// If not already opened and not scheduled
+			if !task.Opened && !task.ForceClosed &&
+				!Scheduled(task.OpenAt, now) {
 				// Open it!
-				log.Println("Open task", task.Name, task.Level)
-				err = db.SetOpened(g.db, task.ID, true)
+				err = g.openTask(teamID, task)
 				if err != nil {
 					return
 				}
@@ -482,28 +879,153 @@ func (g Game) OpenNextTask(t db.Task) (err error) {
 		}
 	}
 
+	err = g.openUnlockedTasks(teamID)
+	return
+}
+
+// getTeam returns team, unknown team is ordinary one, team is test and
+// banned if database fails
+func (g Game) getTeam(teamID int) (team db.Team) {
+
+	team, err := db.GetTeam(g.db, teamID)
+	if err == sql.ErrNoRows {
+		return db.Team{ID: teamID}
+	}
+
+	if err != nil {
+		slog.Error("Get team fail", "team", teamID, "err", err)
+		return db.Team{ID: teamID, Test: true, Banned: true}
+	}
+
 	return
 }
 
 func (g Game) isTestTeam(teamID int) bool {
+	return g.getTeam(teamID).Test
+}
+
+func (g Game) isBannedTeam(teamID int) bool {
+	return g.getTeam(teamID).Banned
+}
+
+// checkFlagSharing add alert if team submit dynamic flag of other team
+func (g Game) checkFlagSharing(task db.Task, teamID int,
+	flag string) (err error) {
 
 	teams, err := db.GetTeams(g.db)
 	if err != nil {
-		log.Println("Get teams fail:", err)
-		return true
+		return
 	}
 
 	for _, team := range teams {
 		if team.ID == teamID {
-			return team.Test
+			continue
+		}
+
+		if !constantTimeEqual(DynamicFlag(task.Flag, task.FlagSecret,
+			team.ID), flag) {
+			continue
 		}
+
+		logging.Audit.Warn("Alert: flag of other team", "team", teamID,
+			"owner", team.ID, "task", task.ID)
+
+		err = db.AddAlert(g.db, &db.Alert{
+			TeamID:  teamID,
+			TaskID:  task.ID,
+			OwnerID: team.ID,
+			Flag:    flag,
+		})
+		return
+	}
+
+	return
+}
+
+// openedForTeam returns true if task is opened for all or unlocked for
+// team in per team mode
+func (g Game) openedForTeam(teamID int, task db.Task) (ok bool, err error) {
+
+	if task.Opened || !g.PerTeam {
+		ok = task.Opened
+		return
+	}
+
+	// opened only for team in per team mode
+
+	unlocked, err := g.unlocked(teamID)
+	if err != nil {
+		return
+	}
+
+	ok = unlocked[task.ID]
+	return
+}
+
+// acceptsWrongFlag returns true if task is opened for team and not solved
+// by it yet, only such wrong flags are penalized
+func (g Game) acceptsWrongFlag(teamID int, task db.Task) (ok bool,
+	err error) {
+
+	solved, err := db.IsSolved(g.db, teamID, task.ID)
+	if err != nil || solved {
+		return
+	}
+
+	ok, err = g.openedForTeam(teamID, task)
+	return
+}
+
+// addWrongFlag keep wrong attempts for audit and penalty
+func (g Game) addWrongFlag(teamID int, task db.Task,
+	flag string) (err error) {
+
+	if !g.running() || g.isTestTeam(teamID) {
+		return
+	}
+
+	ok, err := g.acceptsWrongFlag(teamID, task)
+	if err != nil || !ok {
+		return
 	}
 
-	return false
+	err = db.AddFlag(g.db, &db.Flag{
+		TeamID: teamID,
+		TaskID: task.ID,
+		Flag:   flag,
+		Solved: false,
+	})
+
+	return
 }
 
 // Solve check flag for task and recalc scoreboard if flag correct
 func (g Game) Solve(teamID, taskID int, flag string) (solved bool, err error) {
+	solved, _, err = g.Submit(teamID, taskID, flag)
+	return
+}
+
+// Submit check flag for task, counted is true only for new solve counted
+// on scoreboard (not test or disqualified team, game is running)
+func (g Game) Submit(teamID, taskID int, flag string) (solved, counted bool,
+	err error) {
+
+	team := g.getTeam(teamID)
+
+	if team.Banned {
+		err = errors.New("Team is banned")
+		return
+	}
+
+	state, err := g.State()
+	if err != nil {
+		return
+	}
+
+	if state.Paused {
+		err = errors.New("Game is paused")
+		return
+	}
 
 	tasks, err := db.GetTasks(g.db)
 	if err != nil {
@@ -513,15 +1035,44 @@ func (g Game) Solve(teamID, taskID int, flag string) (solved bool, err error) {
 	for _, task := range tasks {
 		if task.ID == taskID {
 
-			solved, err = regexp.MatchString("^("+task.Flag+")$", flag)
+			var opened bool
+			opened, err = g.openedForTeam(teamID, task)
+			if err != nil {
+				return
+			}
+
+			if !opened {
+				err = errors.New("Task is closed")
+				return
+			}
+
+			var m flagMatcher
+			m, err = g.flags.get(task)
 			if err != nil {
-				log.Println("Match regex fail:", err)
+				slog.Error("Compile flag fail", "task", task.ID,
+					"slug", task.Slug)
 				return
 			}
 
+			solved = m.match(teamID, flag)
+
+			if !solved && task.FlagType == FlagDynamic {
+				err = g.checkFlagSharing(task, teamID, flag)
+				if err != nil {
+					return
+				}
+			}
+
+			if !solved {
+				err = g.addWrongFlag(teamID, task, flag)
+				if err != nil {
+					return
+				}
+			}
+
 			if solved {
 
-				if g.isTestTeam(teamID) {
+				if team.Test {
 					return
 				}
 
@@ -531,9 +1082,7 @@ func (g Game) Solve(teamID, taskID int, flag string) (solved bool, err error) {
 					return
 				}
 
-				now := time.Now()
-
-				if now.After(g.Start) && now.Before(g.End) {
+				if g.running() {
 					err = db.AddFlag(g.db, &db.Flag{
 						TeamID: teamID,
 						TaskID: taskID,
@@ -544,7 +1093,9 @@ func (g Game) Solve(teamID, taskID int, flag string) (solved bool, err error) {
 						return
 					}
 
-					go g.OpenNextTask(task)
+					counted = !team.Disqualified
+
+					go g.OpenNextTask(teamID, task)
 				}
 			}
 
@@ -9,6 +9,7 @@
 package game
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -141,8 +142,8 @@ func addTestData(database *sql.DB, nteams, ncategories, ntasks int,
 
 	for i := 0; i < nteams; i++ {
 
-		team := db.Team{255, fmt.Sprintf("team%d", i),
-			"e", "d", "l", false}
+		team := db.Team{ID: 255, Name: fmt.Sprintf("team%d", i),
+			Email: "e", Desc: "d", Token: "l"}
 
 		err = db.AddTeam(database, &team)
 		if err != nil {
@@ -214,7 +215,7 @@ func TestScoreboard(*testing.T) {
 		panic(err)
 	}
 
-	err = game.Run()
+	err = game.Run(context.Background())
 	if err != nil {
 		panic(err)
 	}
@@ -263,8 +264,8 @@ func fillTestDB(database *sql.DB, validFlag string) (err error) {
 
 	for i := 0; i < nteams; i++ {
 
-		team := db.Team{255, fmt.Sprintf("team%d", i),
-			"e", "d", "l", false}
+		team := db.Team{ID: 255, Name: fmt.Sprintf("team%d", i),
+			Email: "e", Desc: "d", Token: "l"}
 
 		err = db.AddTeam(database, &team)
 		if err != nil {
@@ -346,6 +347,13 @@ func TestSolve(*testing.T) {
 		panic(err)
 	}
 
+	for taskID := 1; taskID <= 3; taskID++ {
+		err = db.SetOpened(database, taskID, true)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	start := time.Now().Add(time.Second)
 	end := start.Add(time.Second)
 
@@ -410,7 +418,7 @@ func TestFirstOpen(*testing.T) {
 		panic(err)
 	}
 
-	game.Run()
+	game.Run(context.Background())
 
 	cats, err := game.Tasks()
 	if err != nil {
@@ -467,7 +475,7 @@ func TestTaskPriceDefaultValues(*testing.T) {
 	database, game := initGame(teamID, taskID, validFlag)
 	defer database.Close()
 
-	game.Run()
+	game.Run(context.Background())
 
 	game.Solve(teamID, taskID, validFlag)
 	game.Solve(teamID, taskID, validFlag)
@@ -493,7 +501,7 @@ func TestTaskPriceFixedValues(*testing.T) {
 
 	game.SetTaskPrice(100, 100, 100, 100)
 
-	game.Run()
+	game.Run(context.Background())
 
 	game.Solve(teamID, taskID, validFlag)
 	game.Solve(teamID, taskID, validFlag)
@@ -513,10 +521,12 @@ func TestAutoOpenTimeoutDisabled(*testing.T) {
 	database, game := initGame(0, 0, "")
 	defer database.Close()
 
-	game.AutoOpen = false
-	game.AutoOpenTimeout = time.Nanosecond
+	game.UpdateSettings(func(s *Settings) {
+		s.AutoOpen = false
+		s.AutoOpenTimeout = time.Nanosecond
+	})
 
-	game.Run()
+	game.Run(context.Background())
 
 	time.Sleep(time.Second * 2)
 
@@ -538,10 +548,12 @@ func TestAutoOpenTimeoutEnabled(*testing.T) {
 	database, game := initGame(0, 0, "")
 	defer database.Close()
 
-	game.AutoOpen = true
-	game.AutoOpenTimeout = time.Nanosecond
+	game.UpdateSettings(func(s *Settings) {
+		s.AutoOpen = true
+		s.AutoOpenTimeout = time.Nanosecond
+	})
 
-	game.Run()
+	game.Run(context.Background())
 
 	time.Sleep(time.Second * 2)
 
@@ -570,3 +582,740 @@ func TestCalcTeamsBase(*testing.T) {
 		panic("Default abount of teams not equal to 21")
 	}
 }
+
+func TestSolveDynamicFlag(*testing.T) {
+
+	database, err := db.InitDatabase(dbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	defer database.Close()
+
+	err = fillTestDB(database, "")
+	if err != nil {
+		panic(err)
+	}
+
+	task, err := db.GetTask(database, 1)
+	if err != nil {
+		panic(err)
+	}
+
+	task.Flag = "flag{%s}"
+	task.FlagType = FlagDynamic
+	task.FlagSecret = "secret"
+	task.Opened = true
+
+	err = db.UpdateTask(database, &task)
+	if err != nil {
+		panic(err)
+	}
+
+	game, err := NewGame(database, time.Now(), time.Now().Add(time.Hour),
+		4)
+	if err != nil {
+		panic(err)
+	}
+
+	flag1 := DynamicFlag(task.Flag, task.FlagSecret, 1)
+
+	solved, err := game.Solve(2, task.ID, flag1)
+	if err != nil {
+		panic(err)
+	}
+
+	if solved {
+		panic("task solved with flag of other team")
+	}
+
+	alerts, err := db.GetAlerts(database)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(alerts) != 1 || alerts[0].TeamID != 2 ||
+		alerts[0].OwnerID != 1 {
+		panic("alert not added")
+	}
+
+	err = testSolveTask(database, &game, 1, task.ID, flag1)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestSolveWrongFlag(*testing.T) {
+
+	validFlag := "testflag"
+
+	database, game := initGame(1, 1, validFlag)
+	defer database.Close()
+
+	err := db.SetOpened(database, 1, true)
+	if err != nil {
+		panic(err)
+	}
+
+	time.Sleep(time.Second) // wait for game start
+
+	solved, err := game.Solve(1, 1, "wrong")
+	if err != nil {
+		panic(err)
+	}
+
+	if solved {
+		panic("solved with wrong flag")
+	}
+
+	flags, err := db.GetFlags(database)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(flags) != 1 || flags[0].Solved || flags[0].Flag != "wrong" {
+		panic("wrong attempt not recorded")
+	}
+
+	scores, err := game.Scoreboard()
+	if err != nil {
+		panic(err)
+	}
+
+	for _, s := range scores {
+		if s.LastAccept != 0 {
+			panic("wrong attempt counted as accept")
+		}
+	}
+}
+
+func TestSolveBannedTeam(*testing.T) {
+
+	validFlag := "testflag"
+
+	database, game := initGame(1, 1, validFlag)
+	defer database.Close()
+
+	time.Sleep(time.Second) // wait for game start
+
+	err := db.SetBanned(database, 1, true, true, "flag sharing")
+	if err != nil {
+		panic(err)
+	}
+
+	solved, err := game.Solve(1, 1, validFlag)
+	if err == nil || solved {
+		panic("banned team solve task")
+	}
+
+	scores, err := game.Scoreboard()
+	if err != nil {
+		panic(err)
+	}
+
+	for _, s := range scores {
+		if s.ID == 1 {
+			panic("disqualified team on scoreboard")
+		}
+	}
+
+	if len(scores) != 3 {
+		panic("not disqualified team hidden from scoreboard")
+	}
+}
+
+func TestAwardsPoints(*testing.T) {
+
+	awards := []db.Award{
+		{TeamID: 1, Points: 100},
+		{TeamID: 2, Points: 50},
+		{TeamID: 1, Points: -30},
+	}
+
+	if AwardsPoints(1, awards) != 70 {
+		panic("invalid awards points")
+	}
+
+	if AwardsPoints(3, awards) != 0 {
+		panic("awards of other teams counted")
+	}
+}
+
+func TestRecalcScoreboardAwards(*testing.T) {
+
+	database, game := initGame(1, 1, "testflag")
+	defer database.Close()
+
+	err := db.AddAward(database, &db.Award{TeamID: 1, Points: 42,
+		Reason: "write-up"})
+	if err != nil {
+		panic(err)
+	}
+
+	err = game.RecalcScoreboard()
+	if err != nil {
+		panic(err)
+	}
+
+	score, err := db.GetLastScore(database, 1)
+	if err != nil {
+		panic(err)
+	}
+
+	if score.Score != 42 {
+		panic("award not included in score")
+	}
+}
+
+func TestWrongFlagPenalty(*testing.T) {
+
+	if WrongFlagPenalty(10, 0, 3) != 30 {
+		panic("invalid penalty")
+	}
+
+	if WrongFlagPenalty(10, 25, 3) != 25 {
+		panic("penalty cap ignored")
+	}
+
+	if WrongFlagPenalty(0, 25, 3) != 0 {
+		panic("disabled penalty applied")
+	}
+}
+
+func TestTaskPenalty(*testing.T) {
+
+	game := Game{settings: newSettings()}
+	game.UpdateSettings(func(s *Settings) { s.Penalty = 10 })
+	task := db.Task{ID: 1}
+	now := time.Now()
+
+	flags := []db.Flag{
+		{TeamID: 1, TaskID: 1, Timestamp: now},
+		{TeamID: 1, TaskID: 1, Solved: true,
+			Timestamp: now.Add(time.Minute)},
+		{TeamID: 1, TaskID: 1, Timestamp: now.Add(time.Hour)},
+		{TeamID: 2, TaskID: 1, Timestamp: now},
+		{TeamID: 1, TaskID: 2, Timestamp: now},
+	}
+
+	penalty, wrong := game.taskPenalty(task, 1, flags)
+	if penalty != 10 || wrong != 1 {
+		panic("wrong flags after solve penalized")
+	}
+
+	penalty, wrong = game.taskPenalty(task, 2, flags)
+	if penalty != 10 || wrong != 1 {
+		panic("wrong flags of unsolved task not penalized")
+	}
+
+	task.Penalty = 3
+	penalty, _ = game.taskPenalty(task, 2, flags)
+	if penalty != 3 {
+		panic("penalty of task ignored")
+	}
+
+	task.Penalty = NoPenalty
+	penalty, wrong = game.taskPenalty(task, 2, flags)
+	if penalty != 0 || wrong != 1 {
+		panic("global penalty applied to task opt out of it")
+	}
+}
+
+func TestPenaltyFromConfig(*testing.T) {
+
+	zero, five := 0, 5
+
+	if PenaltyFromConfig(nil) != 0 ||
+		PenaltyFromConfig(&zero) != NoPenalty ||
+		PenaltyFromConfig(&five) != 5 {
+		panic("invalid penalty from config")
+	}
+
+	for _, p := range []*int{nil, &zero, &five} {
+		back := PenaltyToConfig(PenaltyFromConfig(p))
+		if (back == nil) != (p == nil) || back != nil && *back != *p {
+			panic("penalty is not converted back")
+		}
+	}
+}
+
+// Test settings changed while game is running
+func TestUpdateSettings(*testing.T) {
+
+	game := Game{settings: newSettings()}
+	game.SetTeamsBase(10)
+
+	done := make(chan bool)
+	go func() {
+		game.SetTaskPrice(100, 100, 100, 100)
+		done <- true
+	}()
+
+	game.UpdateSettings(func(s *Settings) { s.Penalty = 10 })
+	<-done
+
+	s := game.Settings()
+	if s.Penalty != 10 || s.TaskPrice.P500 != 1 ||
+		s.TaskPrice.TeamsBase != 10 {
+		panic("settings update lost")
+	}
+}
+
+// Test only first solve of task is counted
+func TestSubmitCounted(*testing.T) {
+
+	validFlag := "testflag"
+
+	database, game := initGame(1, 1, validFlag)
+	defer database.Close()
+
+	err := db.SetOpened(database, 1, true)
+	if err != nil {
+		panic(err)
+	}
+
+	time.Sleep(time.Second) // wait for game start
+
+	solved, counted, err := game.Submit(1, 1, validFlag)
+	if err != nil || !solved || !counted {
+		panic("first solve not counted")
+	}
+
+	solved, counted, err = game.Submit(1, 1, validFlag)
+	if err != nil || !solved || counted {
+		panic("repeated solve counted")
+	}
+
+	err = db.SetBanned(database, 2, false, true, "flag sharing")
+	if err != nil {
+		panic(err)
+	}
+
+	solved, counted, err = game.Submit(2, 1, validFlag)
+	if err != nil || !solved || counted {
+		panic("solve of disqualified team counted")
+	}
+}
+
+// Test wrong flags for solved and closed tasks are not penalized
+func TestSolveWrongFlagNotPenalized(*testing.T) {
+
+	validFlag := "testflag"
+
+	database, game := initGame(1, 1, validFlag)
+	defer database.Close()
+
+	game.UpdateSettings(func(s *Settings) { s.Penalty = 10 })
+
+	err := db.SetOpened(database, 1, true)
+	if err != nil {
+		panic(err)
+	}
+
+	time.Sleep(time.Second) // wait for game start
+
+	solved, err := game.Solve(1, 1, validFlag)
+	if err != nil || !solved {
+		panic("solve task failed")
+	}
+
+	_, err = game.Solve(1, 1, "wrong")
+	if err != nil {
+		panic(err)
+	}
+
+	// task 2 is closed
+	_, err = game.Solve(1, 2, "wrong")
+	if err == nil {
+		panic("flag for closed task accepted")
+	}
+
+	for _, taskID := range []int{1, 2} {
+
+		var wrong int
+		_, wrong, err = game.TaskPenalty(1, taskID)
+		if err != nil {
+			panic(err)
+		}
+
+		if wrong != 0 {
+			panic(fmt.Sprint("wrong flag for task ", taskID,
+				" penalized"))
+		}
+	}
+}
+
+func TestRecalcScoreboardPenalty(*testing.T) {
+
+	validFlag := "testflag"
+
+	database, game := initGame(1, 1, validFlag)
+	defer database.Close()
+
+	game.UpdateSettings(func(s *Settings) {
+		s.Penalty = 10
+		s.PenaltyCap = 25
+	})
+
+	err := db.SetOpened(database, 1, true)
+	if err != nil {
+		panic(err)
+	}
+
+	time.Sleep(time.Second) // wait for game start
+
+	for i := 0; i < 3; i++ {
+		_, err = game.Solve(1, 1, "wrong")
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	penalty, wrong, err := game.TaskPenalty(1, 1)
+	if err != nil {
+		panic(err)
+	}
+
+	if penalty != 25 || wrong != 3 {
+		panic("invalid task penalty")
+	}
+
+	err = game.RecalcScoreboard()
+	if err != nil {
+		panic(err)
+	}
+
+	score, err := db.GetLastScore(database, 1)
+	if err != nil {
+		panic(err)
+	}
+
+	if score.Score != -25 {
+		panic("penalty not included in score")
+	}
+}
+
+func TestOpenUnlockedTasks(*testing.T) {
+
+	validFlag := "testflag"
+
+	database, game := initGame(1, 1, validFlag)
+	defer database.Close()
+
+	first := db.Task{Slug: "first", Name: "first", Flag: validFlag,
+		CategoryID: 1, Level: 10}
+
+	err := db.AddTask(database, &first)
+	if err != nil {
+		panic(err)
+	}
+
+	second := db.Task{Slug: "second", Name: "second", Flag: validFlag,
+		CategoryID: 2, Level: 11, Requires: "first"}
+
+	err = db.AddTask(database, &second)
+	if err != nil {
+		panic(err)
+	}
+
+	time.Sleep(time.Second) // wait for game start
+
+	err = db.SetOpened(database, first.ID, true)
+	if err != nil {
+		panic(err)
+	}
+
+	err = game.openUnlockedTasks(0)
+	if err != nil {
+		panic(err)
+	}
+
+	task, err := db.GetTask(database, second.ID)
+	if err != nil {
+		panic(err)
+	}
+
+	if task.Opened {
+		panic("task opened before requirements solved")
+	}
+
+	solved, err := game.Solve(1, first.ID, validFlag)
+	if err != nil || !solved {
+		panic("solve task failed")
+	}
+
+	err = game.openUnlockedTasks(0)
+	if err != nil {
+		panic(err)
+	}
+
+	task, err = db.GetTask(database, second.ID)
+	if err != nil {
+		panic(err)
+	}
+
+	if !task.Opened {
+		panic("task not opened after requirements solved")
+	}
+}
+
+func taskOpened(cats []CategoryInfo, taskID int) bool {
+	for _, c := range cats {
+		for _, t := range c.TasksInfo {
+			if t.ID == taskID {
+				return t.Opened
+			}
+		}
+	}
+	return false
+}
+
+func TestPerTeamOpenNextTask(*testing.T) {
+
+	database, game := initGame(1, 1, "testflag")
+	defer database.Close()
+
+	game.PerTeam = true
+	game.UpdateSettings(func(s *Settings) { s.OpenTimeout = 0 })
+
+	task, err := db.GetTask(database, 1)
+	if err != nil {
+		panic(err)
+	}
+
+	err = game.OpenNextTask(1, task)
+	if err != nil {
+		panic(err)
+	}
+
+	cats, err := game.TeamTasks(1)
+	if err != nil {
+		panic(err)
+	}
+
+	if !taskOpened(cats, 2) {
+		panic("next task not opened for team")
+	}
+
+	cats, err = game.TeamTasks(2)
+	if err != nil {
+		panic(err)
+	}
+
+	if taskOpened(cats, 2) {
+		panic("next task opened for other team")
+	}
+
+	cats, err = game.Tasks()
+	if err != nil {
+		panic(err)
+	}
+
+	if taskOpened(cats, 2) {
+		panic("next task opened for all")
+	}
+}
+
+// Test correct flag of task locked for team is rejected in per team mode
+func TestPerTeamSolveLockedTask(*testing.T) {
+
+	validFlag := "testflag"
+
+	database, game := initGame(1, 1, validFlag)
+	defer database.Close()
+
+	game.PerTeam = true
+	game.UpdateSettings(func(s *Settings) { s.OpenTimeout = 0 })
+
+	time.Sleep(time.Second) // wait for game start
+
+	solved, err := game.Solve(1, 2, validFlag)
+	if err == nil || solved {
+		panic("flag for locked task accepted")
+	}
+
+	solved, err = db.IsSolved(database, 1, 2)
+	if err != nil {
+		panic(err)
+	}
+
+	if solved {
+		panic("locked task solved")
+	}
+
+	task, err := db.GetTask(database, 1)
+	if err != nil {
+		panic(err)
+	}
+
+	err = game.OpenNextTask(1, task)
+	if err != nil {
+		panic(err)
+	}
+
+	err = testSolveTask(database, &game, 1, 2, validFlag)
+	if err != nil {
+		panic(err)
+	}
+
+	solved, err = game.Solve(2, 2, validFlag)
+	if err == nil || solved {
+		panic("flag for task unlocked for other team accepted")
+	}
+}
+
+// Test open unlocked tasks after restart in per team mode
+func TestPerTeamRestartOpenUnlockedTasks(*testing.T) {
+
+	validFlag := "testflag"
+
+	database, game := initGame(1, 1, validFlag)
+	defer database.Close()
+
+	game.PerTeam = true
+
+	first := db.Task{Slug: "first", Name: "first", Flag: validFlag,
+		CategoryID: 1, Level: 10, Opened: true}
+
+	err := db.AddTask(database, &first)
+	if err != nil {
+		panic(err)
+	}
+
+	second := db.Task{Slug: "second", Name: "second", Flag: validFlag,
+		CategoryID: 2, Level: 11, Requires: "first"}
+
+	err = db.AddTask(database, &second)
+	if err != nil {
+		panic(err)
+	}
+
+	time.Sleep(time.Second) // wait for game start
+
+	solved, err := game.Solve(1, first.ID, validFlag)
+	if err != nil || !solved {
+		panic("solve task failed")
+	}
+
+	// as in Run after restart
+	err = game.openUnlockedTasks(0)
+	if err != nil {
+		panic(err)
+	}
+
+	cats, err := game.TeamTasks(1)
+	if err != nil {
+		panic(err)
+	}
+
+	if !taskOpened(cats, second.ID) {
+		panic("task not opened for team solved requirements")
+	}
+
+	cats, err = game.TeamTasks(2)
+	if err != nil {
+		panic(err)
+	}
+
+	if taskOpened(cats, second.ID) {
+		panic("task opened for other team after restart")
+	}
+
+	task, err := db.GetTask(database, second.ID)
+	if err != nil {
+		panic(err)
+	}
+
+	if task.Opened {
+		panic("task opened for all after restart")
+	}
+}
+
+func TestScheduled(*testing.T) {
+
+	now := time.Now()
+
+	if Scheduled(time.Time{}, now) {
+		panic("task without release time is scheduled")
+	}
+
+	if !Scheduled(now.Add(time.Hour), now) {
+		panic("task with release in future is not scheduled")
+	}
+
+	if Scheduled(now.Add(-time.Hour), now) {
+		panic("released task is scheduled")
+	}
+}
+
+// Test solve does not open next task before its release time
+func TestOpenNextTaskScheduled(*testing.T) {
+
+	database, game := initGame(1, 1, "testflag")
+	defer database.Close()
+
+	game.UpdateSettings(func(s *Settings) { s.OpenTimeout = 0 })
+
+	err := db.SetOpenAt(database, 2, time.Now().Add(time.Hour))
+	if err != nil {
+		panic(err)
+	}
+
+	task, err := db.GetTask(database, 1)
+	if err != nil {
+		panic(err)
+	}
+
+	err = game.OpenNextTask(1, task)
+	if err != nil {
+		panic(err)
+	}
+
+	task, err = db.GetTask(database, 2)
+	if err != nil {
+		panic(err)
+	}
+
+	if task.Opened {
+		panic("scheduled task opened after solve")
+	}
+}
+
+func TestOpenScheduledTasks(*testing.T) {
+
+	database, game := initGame(1, 1, "testflag")
+	defer database.Close()
+
+	err := db.SetOpenAt(database, 1, time.Now().Add(-time.Second))
+	if err != nil {
+		panic(err)
+	}
+
+	err = db.SetOpenAt(database, 2, time.Now().Add(time.Hour))
+	if err != nil {
+		panic(err)
+	}
+
+	err = game.openScheduledTasks()
+	if err != nil {
+		panic(err)
+	}
+
+	task, err := db.GetTask(database, 1)
+	if err != nil {
+		panic(err)
+	}
+
+	if !task.Opened {
+		panic("scheduled task not opened")
+	}
+
+	task, err = db.GetTask(database, 2)
+	if err != nil {
+		panic(err)
+	}
+
+	if task.Opened {
+		panic("task opened before schedule")
+	}
+}
@@ -0,0 +1,208 @@
+/**
+ * @file lint.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief task linter
+ *
+ * Contain checks for task definitions before load it to database
+ */
+
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/jollheef/henhouse/config"
+)
+
+func lintTask(task config.Task) (problems []string) {
+
+	if task.Slug == "" {
+		problems = append(problems, "no slug")
+	} else if _, err := strconv.Atoi(task.Slug); err == nil {
+		problems = append(problems, "numeric slug is ambiguous with id")
+	}
+
+	if task.Category == "" {
+		problems = append(problems, "no category")
+	}
+
+	if task.Name == "" {
+		problems = append(problems, "no russian name")
+	}
+
+	if task.NameEn == "" {
+		problems = append(problems, "no english name")
+	}
+
+	if task.Description == "" {
+		problems = append(problems, "no russian description")
+	}
+
+	if task.DescriptionEn == "" {
+		problems = append(problems, "no english description")
+	}
+
+	if task.Penalty != nil && *task.Penalty < 0 {
+		problems = append(problems, "negative penalty")
+	}
+
+	if task.Flag == "" {
+		problems = append(problems, "no flag")
+		return
+	}
+
+	m, err := newFlagMatcher(task.FlagType, task.Flag, task.FlagSecret)
+	if err != nil {
+		problems = append(problems, "invalid flag: "+err.Error())
+		return
+	}
+
+	if task.FlagType == FlagDynamic {
+		// example flag is different for each team
+		return
+	}
+
+	if task.ExampleFlag == "" {
+		problems = append(problems, "no example flag")
+	} else if !m.match(0, task.ExampleFlag) {
+		problems = append(problems, "example flag does not match")
+	}
+
+	return
+}
+
+// lintLevels check that levels in each category has no gaps, otherwise
+// OpenNextTask never open tasks after gap
+func lintLevels(tasks []config.Task) (problems []string) {
+
+	levels := make(map[string][]int)
+	for _, task := range tasks {
+		if len(task.Requires) != 0 {
+			// not a part of level chain
+			continue
+		}
+		levels[task.Category] = append(levels[task.Category],
+			task.Level)
+	}
+
+	var categories []string
+	for category := range levels {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	for _, category := range categories {
+		l := levels[category]
+		sort.Ints(l)
+		for i := 1; i < len(l); i++ {
+			if l[i] > l[i-1]+1 {
+				problems = append(problems, fmt.Sprintf(
+					"category %s: no tasks between "+
+						"level %d and %d",
+					category, l[i-1], l[i]))
+			}
+		}
+	}
+
+	return
+}
+
+// lintCycles returns tasks never opened because of cycle in requirements,
+// tasks opened one by one from tasks without requirements until nothing
+// changes, so any-of requirement with other way out is not a problem
+func lintCycles(tasks []config.Task) (problems []string) {
+
+	known := make(map[string]bool)
+	for _, task := range tasks {
+		known[task.Slug] = true
+	}
+
+	opened := make(map[string]bool)
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, task := range tasks {
+			if opened[task.Slug] {
+				continue
+			}
+
+			// unknown and self requirements are reported separately
+			var requires []string
+			for _, slug := range task.Requires {
+				if known[slug] && slug != task.Slug {
+					requires = append(requires, slug)
+				}
+			}
+
+			if RequirementsMet(requires, task.RequiresAny, opened) {
+				opened[task.Slug] = true
+				changed = true
+			}
+		}
+	}
+
+	for _, task := range tasks {
+		if !opened[task.Slug] {
+			problems = append(problems, task.Slug+
+				": requirements can never be met (cycle)")
+		}
+	}
+
+	return
+}
+
+// LintTasks returns all problems found in task definitions
+func LintTasks(tasks []config.Task) (problems []string) {
+
+	slugs := make(map[string]int)
+	names := make(map[string]int)
+	namesEn := make(map[string]int)
+
+	for _, task := range tasks {
+		for _, p := range lintTask(task) {
+			problems = append(problems, task.Slug+": "+p)
+		}
+
+		slugs[task.Slug]++
+		if task.Name != "" {
+			names[task.Name]++
+		}
+		if task.NameEn != "" {
+			namesEn[task.NameEn]++
+		}
+	}
+
+	for _, task := range tasks {
+		if slugs[task.Slug] > 1 {
+			problems = append(problems, task.Slug+
+				": duplicate slug")
+		}
+		if names[task.Name] > 1 {
+			problems = append(problems, task.Slug+
+				": duplicate name "+task.Name)
+		}
+		if namesEn[task.NameEn] > 1 {
+			problems = append(problems, task.Slug+
+				": duplicate english name "+task.NameEn)
+		}
+		for _, slug := range task.Requires {
+			if slug == task.Slug {
+				problems = append(problems, task.Slug+
+					": requires itself")
+			} else if slugs[slug] == 0 {
+				problems = append(problems, task.Slug+
+					": requires unknown task "+slug)
+			}
+		}
+	}
+
+	problems = append(problems, lintLevels(tasks)...)
+	problems = append(problems, lintCycles(tasks)...)
+
+	return
+}
@@ -0,0 +1,138 @@
+/**
+ * @file lint_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief test task linter
+ */
+
+package game
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jollheef/henhouse/config"
+)
+
+func validTask(slug string, level int) config.Task {
+	return config.Task{
+		Slug:          slug,
+		Name:          "name " + slug,
+		NameEn:        "name en " + slug,
+		Description:   "desc",
+		DescriptionEn: "desc en",
+		Category:      "web",
+		Level:         level,
+		Flag:          "flag_[0-9]+",
+		ExampleFlag:   "flag_42",
+	}
+}
+
+func hasProblem(problems []string, substr string) bool {
+	for _, p := range problems {
+		if strings.Contains(p, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLintTasks(*testing.T) {
+
+	tasks := []config.Task{validTask("web1", 1), validTask("web2", 2)}
+
+	problems := LintTasks(tasks)
+	if len(problems) != 0 {
+		panic("problems in valid tasks: " + strings.Join(problems, ", "))
+	}
+
+	tasks = append(tasks, validTask("web4", 4))
+	if !hasProblem(LintTasks(tasks), "between level 2 and 4") {
+		panic("level gap not found")
+	}
+
+	tasks[2].Level = 3
+	tasks[2].Flag = "flag_("
+	if !hasProblem(LintTasks(tasks), "invalid flag") {
+		panic("invalid regex not found")
+	}
+
+	tasks[2].Flag = "flag_[a-z]+"
+	if !hasProblem(LintTasks(tasks), "example flag does not match") {
+		panic("mismatch of example flag not found")
+	}
+
+	tasks[2] = validTask("web1", 3)
+	tasks[2].NameEn = ""
+	problems = LintTasks(tasks)
+	if !hasProblem(problems, "duplicate slug") ||
+		!hasProblem(problems, "duplicate name") ||
+		!hasProblem(problems, "no english name") {
+		panic("duplicates or missing translation not found")
+	}
+}
+
+func TestLintRequires(*testing.T) {
+
+	tasks := []config.Task{validTask("web1", 1), validTask("web5", 5)}
+	tasks[1].Requires = []string{"web1"}
+
+	problems := LintTasks(tasks)
+	if len(problems) != 0 {
+		panic("problems in valid tasks: " + strings.Join(problems, ", "))
+	}
+
+	tasks[1].Requires = []string{"web5", "web2"}
+	problems = LintTasks(tasks)
+	if !hasProblem(problems, "requires itself") ||
+		!hasProblem(problems, "requires unknown task web2") {
+		panic("invalid requirements not found")
+	}
+
+	if hasProblem(problems, "cycle") {
+		panic("self requirement reported as cycle")
+	}
+}
+
+func TestLintCycles(*testing.T) {
+
+	tasks := []config.Task{validTask("web1", 1), validTask("web2", 2),
+		validTask("web3", 3)}
+	tasks[0].Requires = []string{"web2"}
+	tasks[1].Requires = []string{"web1"}
+
+	problems := LintTasks(tasks)
+	if !hasProblem(problems, "web1: requirements can never be met") ||
+		!hasProblem(problems, "web2: requirements can never be met") ||
+		hasProblem(problems, "web3: requirements") {
+		panic("cycle not found: " + strings.Join(problems, ", "))
+	}
+
+	// other way out of cycle
+	tasks[0].Requires = []string{"web2", "web3"}
+	tasks[0].RequiresAny = true
+
+	problems = LintTasks(tasks)
+	if hasProblem(problems, "cycle") {
+		panic("cycle with way out found: " + strings.Join(problems, ", "))
+	}
+}
+
+func TestLintPenalty(*testing.T) {
+
+	penalty := -1
+
+	tasks := []config.Task{validTask("web1", 1)}
+	tasks[0].Penalty = &penalty
+
+	if !hasProblem(LintTasks(tasks), "negative penalty") {
+		panic("negative penalty not found")
+	}
+
+	penalty = 0
+
+	if hasProblem(LintTasks(tasks), "penalty") {
+		panic("disabled penalty reported")
+	}
+}
@@ -0,0 +1,136 @@
+/**
+ * @file requires.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief task prerequisites
+ *
+ * Contain functions for open tasks after solve of required tasks
+ */
+
+package game
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jollheef/henhouse/db"
+)
+
+// RequiredSlugs returns slugs of tasks required by task
+func RequiredSlugs(task db.Task) (slugs []string) {
+	for _, slug := range strings.Split(task.Requires, ",") {
+		slug = strings.TrimSpace(slug)
+		if slug != "" {
+			slugs = append(slugs, slug)
+		}
+	}
+	return
+}
+
+// RequirementsMet returns true if all (or any) of required tasks solved
+func RequirementsMet(requires []string, any bool,
+	solved map[string]bool) bool {
+
+	for _, slug := range requires {
+		if solved[slug] && any {
+			return true
+		}
+		if !solved[slug] && !any {
+			return false
+		}
+	}
+
+	return !any || len(requires) == 0
+}
+
+// solvedSlugs returns slugs of tasks solved by team, by any team if
+// team id is zero
+func solvedSlugs(tasks []db.Task, flags []db.Flag,
+	teamID int) map[string]bool {
+
+	slugs := make(map[int]string)
+	for _, t := range tasks {
+		slugs[t.ID] = t.Slug
+	}
+
+	solved := make(map[string]bool)
+	for _, f := range flags {
+		if f.Solved && (teamID == 0 || f.TeamID == teamID) {
+			solved[slugs[f.TaskID]] = true
+		}
+	}
+
+	return solved
+}
+
+// openUnlockedTasks open closed tasks with solved requirements, in per team
+// mode requirements must be solved by team itself, all teams are checked
+// if team id is zero
+func (g Game) openUnlockedTasks(teamID int) (err error) {
+
+	if !g.PerTeam {
+		teamID = 0
+	} else if teamID == 0 {
+		return g.openTeamsUnlockedTasks()
+	}
+
+	tasks, err := db.GetTasks(g.db)
+	if err != nil {
+		return
+	}
+
+	flags, err := db.GetFlags(g.db)
+	if err != nil {
+		return
+	}
+
+	solved := solvedSlugs(tasks, flags, teamID)
+
+	unlocked := make(map[int]bool)
+	if teamID != 0 {
+		unlocked, err = g.unlocked(teamID)
+		if err != nil {
+			return
+		}
+	}
+
+	now := time.Now()
+
+	for _, task := range tasks {
+		if task.Opened || unlocked[task.ID] || task.ForceClosed ||
+			task.Requires == "" || Scheduled(task.OpenAt, now) {
+			continue
+		}
+
+		if !RequirementsMet(RequiredSlugs(task), task.RequiresAny,
+			solved) {
+			continue
+		}
+
+		err = g.openTask(teamID, task)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// openTeamsUnlockedTasks open tasks unlocked by each team in per team mode
+func (g Game) openTeamsUnlockedTasks() (err error) {
+
+	teams, err := db.GetTeams(g.db)
+	if err != nil {
+		return
+	}
+
+	for _, team := range teams {
+		err = g.openUnlockedTasks(team.ID)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
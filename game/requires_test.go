@@ -0,0 +1,50 @@
+/**
+ * @file requires_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief test task prerequisites
+ */
+
+package game
+
+import (
+	"testing"
+
+	"github.com/jollheef/henhouse/db"
+)
+
+func TestRequirementsMet(*testing.T) {
+
+	solved := map[string]bool{"web1": true}
+	requires := []string{"web1", "crypto1"}
+
+	if RequirementsMet(requires, false, solved) {
+		panic("all-of requirements met with one solved")
+	}
+
+	if !RequirementsMet(requires, true, solved) {
+		panic("any-of requirements not met with one solved")
+	}
+
+	solved["crypto1"] = true
+	if !RequirementsMet(requires, false, solved) {
+		panic("all-of requirements not met")
+	}
+
+	if RequirementsMet(requires, true, map[string]bool{}) {
+		panic("any-of requirements met without solves")
+	}
+}
+
+func TestRequiredSlugs(*testing.T) {
+
+	slugs := RequiredSlugs(db.Task{Requires: "web1, crypto1,"})
+	if len(slugs) != 2 || slugs[0] != "web1" || slugs[1] != "crypto1" {
+		panic("invalid required slugs")
+	}
+
+	if len(RequiredSlugs(db.Task{})) != 0 {
+		panic("required slugs of task without requirements")
+	}
+}
@@ -0,0 +1,200 @@
+/**
+ * @file state.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief game timing
+ *
+ * Contain functions for pause, resume and extend game at runtime and
+ * for freeze of public scoreboard
+ */
+
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jollheef/henhouse/db"
+)
+
+// InitState set timing of game from configuration if there is no game in
+// database yet, afterwards database is the only source of timing and it is
+// changed only by henhousectl game
+func InitState(database *sql.DB, start, end, freeze time.Time) (
+	state db.Game, err error) {
+
+	state = db.Game{Start: start, End: end, Freeze: freeze}
+	err = db.InitGame(database, &state)
+	return
+}
+
+// State returns current timing of game
+func (g Game) State() (state db.Game, err error) {
+	return db.GetGame(g.db)
+}
+
+// Running returns true if game started, not ended and not paused
+func Running(state db.Game, now time.Time) bool {
+	return !state.Paused && now.After(state.Start) && now.Before(state.End)
+}
+
+func (g Game) running() bool {
+
+	state, err := g.State()
+	if err != nil {
+		slog.Error("Get game state fail", "err", err)
+		return false
+	}
+
+	return Running(state, time.Now())
+}
+
+// Left returns state of game (not started, running, paused or completed)
+// and time left before start or end of game
+func Left(state db.Game, now time.Time) (left time.Duration,
+	started, ended bool) {
+
+	if state.Paused {
+		// countdown is frozen at the moment of pause
+		now = state.PausedAt
+	}
+
+	if now.Before(state.Start) {
+		left = state.Start.Sub(now)
+	} else if now.Before(state.End) {
+		started = true
+		left = state.End.Sub(now)
+	} else {
+		started = true
+		ended = true
+	}
+
+	return
+}
+
+// Pause stop countdown and reject flags until resume
+func Pause(database *sql.DB) (err error) {
+
+	state, err := db.GetGame(database)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+
+	if state.Paused {
+		err = errors.New("Game already paused")
+		return
+	}
+
+	if now.After(state.End) {
+		err = errors.New("Game is over")
+		return
+	}
+
+	state.Paused = true
+	state.PausedAt = now
+
+	return db.UpdateGame(database, &state)
+}
+
+// ShiftFreeze move freeze of scoreboard by duration if it is set and not
+// reached at the moment, already frozen scoreboard stays frozen
+func ShiftFreeze(state *db.Game, at time.Time, d time.Duration) {
+	if !state.Freeze.IsZero() && state.Freeze.After(at) {
+		state.Freeze = state.Freeze.Add(d)
+	}
+}
+
+// Resume continue countdown, end and freeze (and start if game paused
+// before start) are moved by duration of pause
+func Resume(database *sql.DB) (err error) {
+
+	state, err := db.GetGame(database)
+	if err != nil {
+		return
+	}
+
+	if !state.Paused {
+		err = errors.New("Game is not paused")
+		return
+	}
+
+	pause := time.Since(state.PausedAt)
+
+	if state.PausedAt.Before(state.Start) {
+		state.Start = state.Start.Add(pause)
+	}
+	state.End = state.End.Add(pause)
+	ShiftFreeze(&state, state.PausedAt, pause)
+	state.Paused = false
+
+	return db.UpdateGame(database, &state)
+}
+
+// Extend move end (and freeze) of game
+func Extend(database *sql.DB, d time.Duration) (err error) {
+
+	state, err := db.GetGame(database)
+	if err != nil {
+		return
+	}
+
+	state.End = state.End.Add(d)
+	ShiftFreeze(&state, time.Now(), d)
+
+	if !state.End.After(state.Start) {
+		err = errors.New("End of game before start")
+		return
+	}
+
+	return db.UpdateGame(database, &state)
+}
+
+// Frozen returns true if public scoreboard is frozen
+func Frozen(state db.Game, now time.Time) bool {
+	return !state.Freeze.IsZero() && !state.Unfrozen &&
+		now.After(state.Freeze)
+}
+
+func flagsBefore(flags []db.Flag, before time.Time) (found []db.Flag) {
+	for _, f := range flags {
+		if f.Timestamp.Before(before) {
+			found = append(found, f)
+		}
+	}
+	return
+}
+
+// SetFreeze set time of scoreboard freeze, zero time disable freeze,
+// new freeze cancels previous unfreeze
+func SetFreeze(database *sql.DB, freeze time.Time) (err error) {
+
+	state, err := db.GetGame(database)
+	if err != nil {
+		return
+	}
+
+	state.Freeze = freeze
+	if !freeze.IsZero() {
+		state.Unfrozen = false
+	}
+
+	return db.UpdateGame(database, &state)
+}
+
+// Unfreeze show real scoreboard to public
+func Unfreeze(database *sql.DB) (err error) {
+
+	state, err := db.GetGame(database)
+	if err != nil {
+		return
+	}
+
+	state.Unfrozen = true
+
+	return db.UpdateGame(database, &state)
+}
@@ -0,0 +1,301 @@
+/**
+ * @file state_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief test game timing
+ */
+
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jollheef/henhouse/db"
+)
+
+func TestRunning(*testing.T) {
+
+	now := time.Now()
+
+	state := db.Game{Start: now.Add(-time.Hour), End: now.Add(time.Hour)}
+
+	if !Running(state, now) {
+		panic("game not running")
+	}
+
+	if Running(state, now.Add(2*time.Hour)) {
+		panic("game running after end")
+	}
+
+	state.Paused = true
+	if Running(state, now) {
+		panic("paused game running")
+	}
+}
+
+func TestLeft(*testing.T) {
+
+	now := time.Now()
+
+	state := db.Game{Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}
+
+	left, started, ended := Left(state, now)
+	if left != time.Hour || started || ended {
+		panic("invalid left before start")
+	}
+
+	left, started, ended = Left(state, now.Add(90*time.Minute))
+	if left != 30*time.Minute || !started || ended {
+		panic("invalid left before end")
+	}
+
+	state.Paused = true
+	state.PausedAt = now.Add(90 * time.Minute)
+
+	left, _, _ = Left(state, now.Add(100*time.Minute))
+	if left != 30*time.Minute {
+		panic("countdown not frozen")
+	}
+
+	_, _, ended = Left(db.Game{End: now}, now.Add(time.Second))
+	if !ended {
+		panic("game not ended")
+	}
+}
+
+func TestFrozen(*testing.T) {
+
+	now := time.Now()
+
+	if Frozen(db.Game{}, now) {
+		panic("frozen without freeze time")
+	}
+
+	state := db.Game{Freeze: now.Add(-time.Minute)}
+	if !Frozen(state, now) {
+		panic("not frozen after freeze time")
+	}
+
+	if Frozen(state, now.Add(-time.Hour)) {
+		panic("frozen before freeze time")
+	}
+
+	state.Unfrozen = true
+	if Frozen(state, now) {
+		panic("frozen after unfreeze")
+	}
+}
+
+func TestShiftFreeze(*testing.T) {
+
+	now := time.Now()
+
+	state := db.Game{Freeze: now.Add(time.Minute)}
+	ShiftFreeze(&state, now, time.Hour)
+	if !state.Freeze.Equal(now.Add(time.Hour + time.Minute)) {
+		panic("freeze is not moved")
+	}
+
+	state = db.Game{Freeze: now.Add(-time.Minute)}
+	ShiftFreeze(&state, now, time.Hour)
+	if !state.Freeze.Equal(now.Add(-time.Minute)) {
+		panic("reached freeze is moved")
+	}
+
+	state = db.Game{}
+	ShiftFreeze(&state, now, time.Hour)
+	if !state.Freeze.IsZero() {
+		panic("disabled freeze is enabled")
+	}
+}
+
+func TestPauseResume(*testing.T) {
+
+	validFlag := "testflag"
+
+	database, game := initGame(1, 1, validFlag)
+	defer database.Close()
+
+	err := db.SetOpened(database, 1, true)
+	if err != nil {
+		panic(err)
+	}
+
+	time.Sleep(time.Second) // wait for game start
+
+	err = SetFreeze(database, time.Now().Add(time.Minute))
+	if err != nil {
+		panic(err)
+	}
+
+	before, err := game.State()
+	if err != nil {
+		panic(err)
+	}
+
+	err = Pause(database)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = game.Solve(1, 1, validFlag)
+	if err == nil {
+		panic("flag accepted on pause")
+	}
+
+	err = Resume(database)
+	if err != nil {
+		panic(err)
+	}
+
+	err = Extend(database, time.Hour)
+	if err != nil {
+		panic(err)
+	}
+
+	after, err := game.State()
+	if err != nil {
+		panic(err)
+	}
+
+	if after.Paused || !after.End.After(before.End.Add(time.Hour)) {
+		panic("invalid game state after resume")
+	}
+
+	if !after.Freeze.After(before.Freeze.Add(time.Hour)) {
+		panic("freeze is not moved by pause and extend")
+	}
+
+	solved, err := game.Solve(1, 1, validFlag)
+	if err != nil || !solved {
+		panic("flag rejected after resume")
+	}
+}
+
+func teamScore(scores []TeamScoreInfo, teamID int) int {
+	for _, s := range scores {
+		if s.ID == teamID {
+			return s.Score
+		}
+	}
+	return -1
+}
+
+func TestScoreboardFreeze(*testing.T) {
+
+	database, game := initGame(1, 1, "testflag")
+	defer database.Close()
+
+	err := game.RecalcScoreboard()
+	if err != nil {
+		panic(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	err = SetFreeze(database, time.Now())
+	if err != nil {
+		panic(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	err = db.AddAward(database, &db.Award{TeamID: 1, Points: 100})
+	if err != nil {
+		panic(err)
+	}
+
+	err = game.RecalcScoreboard()
+	if err != nil {
+		panic(err)
+	}
+
+	scores, err := game.Scoreboard()
+	if err != nil {
+		panic(err)
+	}
+
+	if teamScore(scores, 1) != 0 {
+		panic("public scoreboard not frozen")
+	}
+
+	scores, err = game.RealScoreboard()
+	if err != nil {
+		panic(err)
+	}
+
+	if teamScore(scores, 1) != 100 {
+		panic("invalid real scoreboard")
+	}
+
+	err = Unfreeze(database)
+	if err != nil {
+		panic(err)
+	}
+
+	scores, err = game.Scoreboard()
+	if err != nil {
+		panic(err)
+	}
+
+	if teamScore(scores, 1) != 100 {
+		panic("public scoreboard frozen after unfreeze")
+	}
+
+	err = SetFreeze(database, time.Now())
+	if err != nil {
+		panic(err)
+	}
+
+	state, err := game.State()
+	if err != nil {
+		panic(err)
+	}
+
+	if state.Unfrozen {
+		panic("new freeze has no effect after unfreeze")
+	}
+}
+
+func TestInitState(*testing.T) {
+
+	database, err := db.InitDatabase(dbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	defer database.Close()
+
+	start := time.Now().Truncate(time.Second)
+	end := start.Add(time.Hour)
+	freeze := end.Add(-10 * time.Minute)
+
+	state, err := InitState(database, start, end, freeze)
+	if err != nil {
+		panic(err)
+	}
+
+	if !state.Start.Equal(start) || !state.End.Equal(end) ||
+		!state.Freeze.Equal(freeze) {
+		panic("timing of new game is not set")
+	}
+
+	err = SetFreeze(database, time.Time{})
+	if err != nil {
+		panic(err)
+	}
+
+	// restart with other configuration
+	state, err = InitState(database, start.Add(time.Hour),
+		end.Add(time.Hour), freeze.Add(time.Hour))
+	if err != nil {
+		panic(err)
+	}
+
+	if !state.Start.Equal(start) || !state.End.Equal(end) ||
+		!state.Freeze.IsZero() {
+		panic("timing of existing game is overridden by configuration")
+	}
+}
@@ -0,0 +1,344 @@
+/**
+ * @file sync.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief synchronize tasks with task directory
+ *
+ * Contain functions for load tasks from xml without reinit database
+ */
+
+package game
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jollheef/henhouse/config"
+	"github.com/jollheef/henhouse/db"
+)
+
+// SyncReport provide slugs of tasks changed by synchronization
+type SyncReport struct {
+	Added   []string
+	Updated []string
+	Removed []string // only reported, never removed from database
+}
+
+// Empty returns true if nothing changed
+func (r SyncReport) Empty() bool {
+	return len(r.Added) == 0 && len(r.Updated) == 0 && len(r.Removed) == 0
+}
+
+func fillTranslateFallback(task *config.Task) {
+	if task.NameEn == "" {
+		task.NameEn = task.Name
+	}
+	if task.Name == "" {
+		task.Name = task.NameEn
+	}
+	if task.DescriptionEn == "" {
+		task.DescriptionEn = task.Description
+	}
+	if task.Description == "" {
+		task.Description = task.DescriptionEn
+	}
+}
+
+// NoPenalty is penalty of task disables global penalty, because zero
+// penalty of task means global penalty
+const NoPenalty = -1
+
+// PenaltyFromConfig convert penalty of task, nil means global penalty
+func PenaltyFromConfig(penalty *int) int {
+	if penalty == nil {
+		return 0
+	}
+
+	if *penalty == 0 {
+		return NoPenalty
+	}
+
+	return *penalty
+}
+
+// PenaltyToConfig is reverse of PenaltyFromConfig
+func PenaltyToConfig(penalty int) *int {
+	if penalty == 0 {
+		return nil
+	}
+
+	if penalty < 0 {
+		penalty = 0
+	}
+
+	return &penalty
+}
+
+// TaskFromConfig convert parsed task to closed task row
+func TaskFromConfig(task config.Task, categoryID int) db.Task {
+
+	fillTranslateFallback(&task)
+
+	var openAt time.Time
+	if task.OpenAt != nil {
+		openAt = *task.OpenAt
+	}
+
+	var files []string
+	for _, file := range task.Files {
+		files = append(files, filepath.Join(task.Dir, file))
+	}
+
+	return db.Task{
+		Slug:          task.Slug,
+		Name:          task.Name,
+		Desc:          task.Description,
+		NameEn:        task.NameEn,
+		DescEn:        task.DescriptionEn,
+		Tags:          task.Tags,
+		CategoryID:    categoryID,
+		Level:         task.Level,
+		Flag:          task.Flag,
+		FlagType:      task.FlagType,
+		FlagSecret:    task.FlagSecret,
+		Penalty:       PenaltyFromConfig(task.Penalty),
+		Requires:      strings.Join(task.Requires, ","),
+		RequiresAny:   task.RequiresAny,
+		Price:         500,   // TODO support non-shared task
+		Shared:        true,  // TODO support non-shared task
+		MaxSharePrice: 500,   // TODO support value from xml
+		MinSharePrice: 100,   // TODO support value from xml
+		Opened:        false, // by default task is closed
+		Author:        task.Author,
+		ForceClosed:   task.ForceClosed,
+		OpenAt:        openAt,
+		Hints:         task.Hints,
+		Files:         strings.Join(files, "\n"),
+	}
+}
+
+// sameDefinition compare only fields that comes from task xml
+func sameDefinition(a, b db.Task) bool {
+	return a.Slug == b.Slug && a.Name == b.Name && a.Desc == b.Desc &&
+		a.NameEn == b.NameEn && a.DescEn == b.DescEn &&
+		a.Tags == b.Tags && a.CategoryID == b.CategoryID &&
+		a.Level == b.Level && a.Flag == b.Flag &&
+		a.FlagType == b.FlagType && a.FlagSecret == b.FlagSecret &&
+		a.Penalty == b.Penalty && a.Requires == b.Requires &&
+		a.RequiresAny == b.RequiresAny && a.OpenAt.Equal(b.OpenAt) &&
+		a.Author == b.Author && a.ForceClosed == b.ForceClosed &&
+		a.Hints == b.Hints && a.Files == b.Files
+}
+
+func categoryID(database *sql.DB, categories *[]db.Category,
+	name string, dryRun bool) (id int, err error) {
+
+	for _, cat := range *categories {
+		if cat.Name == name {
+			return cat.ID, nil
+		}
+	}
+
+	category := db.Category{Name: name}
+
+	if !dryRun {
+		err = db.AddCategory(database, &category)
+		if err != nil {
+			return
+		}
+
+		slog.Info("Add category", "category", category.Name)
+	}
+
+	*categories = append(*categories, category)
+
+	return category.ID, nil
+}
+
+// matchSlugs returns slugs for tasks created before slugs, such tasks are
+// matched with loaded tasks by name and category
+func matchSlugs(current, loaded []db.Task) (slugs map[int]string,
+	err error) {
+
+	used := make(map[string]bool)
+	for _, t := range current {
+		used[t.Slug] = true
+	}
+
+	slugs = make(map[int]string)
+
+	for _, t := range current {
+		if t.Slug != "" {
+			continue
+		}
+
+		var found []string
+		for _, l := range loaded {
+			if l.Name == t.Name && l.CategoryID == t.CategoryID &&
+				!used[l.Slug] {
+				found = append(found, l.Slug)
+			}
+		}
+
+		if len(found) != 1 {
+			err = fmt.Errorf("Task %d (%s) has no slug, set it by "+
+				"henhousectl task update %d <xml>", t.ID, t.Name,
+				t.ID)
+			return
+		}
+
+		slugs[t.ID] = found[0]
+		used[found[0]] = true
+	}
+
+	return
+}
+
+// SyncTasks add new and update changed tasks, tasks are identified by slug.
+// Team progress, opened state and task ids are never touched.
+func SyncTasks(database *sql.DB, tasks []config.Task,
+	dryRun bool) (report SyncReport, err error) {
+
+	categories, err := db.GetCategories(database)
+	if err != nil {
+		return
+	}
+
+	current, err := db.GetTasks(database)
+	if err != nil {
+		return
+	}
+
+	var converted []db.Task
+
+	for _, task := range tasks {
+
+		var catID int
+		catID, err = categoryID(database, &categories,
+			task.Category, dryRun)
+		if err != nil {
+			return
+		}
+
+		t := TaskFromConfig(task, catID)
+
+		if t.Slug == "" {
+			err = errors.New("Task " + t.Name + " has no slug")
+			return
+		}
+
+		converted = append(converted, t)
+	}
+
+	slugs, err := matchSlugs(current, converted)
+	if err != nil {
+		return
+	}
+
+	// task without slug in database is updated, because slug differs
+	existing := make(map[string]db.Task)
+	for _, t := range current {
+		if t.Slug == "" {
+			existing[slugs[t.ID]] = t
+		} else {
+			existing[t.Slug] = t
+		}
+	}
+
+	loaded := make(map[string]bool)
+
+	for i, task := range tasks {
+
+		t := converted[i]
+
+		loaded[t.Slug] = true
+
+		old, ok := existing[t.Slug]
+		if ok && task.OpenAt == nil {
+			// keep schedule from henhousectl task schedule
+			t.OpenAt = old.OpenAt
+		}
+
+		if !ok {
+			if !dryRun {
+				err = db.AddTask(database, &t)
+				if err != nil {
+					return
+				}
+
+				slog.Info("Add task", "task", t.Slug)
+			}
+
+			report.Added = append(report.Added, t.Slug)
+			continue
+		}
+
+		if sameDefinition(old, t) {
+			continue
+		}
+
+		if !dryRun {
+			t.ID = old.ID
+			t.Opened = old.Opened
+			t.OpenedTime = old.OpenedTime
+
+			err = db.UpdateTask(database, &t)
+			if err != nil {
+				return
+			}
+
+			slog.Info("Update task", "task", t.Slug)
+		}
+
+		report.Updated = append(report.Updated, t.Slug)
+	}
+
+	for _, t := range current {
+		if t.Slug == "" {
+			t.Slug = slugs[t.ID]
+		}
+
+		if !loaded[t.Slug] {
+			report.Removed = append(report.Removed, t.Slug)
+		}
+	}
+
+	return
+}
+
+// TaskDirWatcher periodically synchronize tasks with task directory
+// until cancel of context
+func TaskDirWatcher(ctx context.Context, database *sql.DB, dir string,
+	timeout time.Duration) {
+
+	removed := make(map[string]bool)
+
+	for sleep(ctx, timeout) {
+
+		tasks, err := config.ReadTaskDir(dir)
+		if err != nil {
+			slog.Error("Read task dir fail", "err", err)
+			continue
+		}
+
+		report, err := SyncTasks(database, tasks, false)
+		if err != nil {
+			slog.Error("Sync tasks fail", "err", err)
+			continue
+		}
+
+		for _, name := range report.Removed {
+			if !removed[name] {
+				slog.Warn("Task removed from task dir", "task", name)
+				removed[name] = true
+			}
+		}
+	}
+}
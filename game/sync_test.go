@@ -0,0 +1,200 @@
+/**
+ * @file sync_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief test synchronize tasks
+ */
+
+package game
+
+import (
+	"testing"
+
+	"github.com/jollheef/henhouse/config"
+	"github.com/jollheef/henhouse/db"
+)
+
+func TestSyncTasks(*testing.T) {
+
+	database, err := db.InitDatabase(dbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	defer database.Close()
+
+	tasks := []config.Task{
+		{Slug: "web1", Name: "task1", Category: "web", Level: 1,
+			Flag: "flag1"},
+		{Slug: "web2", Name: "task2", Category: "web", Level: 2,
+			Flag: "flag2"},
+	}
+
+	report, err := SyncTasks(database, tasks, false)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(report.Added) != 2 {
+		panic("tasks not added")
+	}
+
+	err = db.SetOpened(database, 1, true)
+	if err != nil {
+		panic(err)
+	}
+
+	err = db.AddFlag(database, &db.Flag{TeamID: 1, TaskID: 1,
+		Flag: "flag1", Solved: true})
+	if err != nil {
+		panic(err)
+	}
+
+	tasks[0].Flag = "newflag1"
+	tasks = tasks[:1]
+	tasks = append(tasks, config.Task{Slug: "crypto1",
+		Name: "task3", Category: "crypto"})
+
+	report, err = SyncTasks(database, tasks, true)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(report.Added) != 1 || len(report.Updated) != 1 ||
+		len(report.Removed) != 1 {
+		panic("invalid dry run report")
+	}
+
+	dbTasks, err := db.GetTasks(database)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(dbTasks) != 2 {
+		panic("dry run changes database")
+	}
+
+	_, err = SyncTasks(database, tasks, false)
+	if err != nil {
+		panic(err)
+	}
+
+	task, err := db.GetTask(database, 1)
+	if err != nil {
+		panic(err)
+	}
+
+	if task.Flag != "newflag1" || !task.Opened {
+		panic("invalid task update")
+	}
+
+	solved, err := db.IsSolved(database, 1, 1)
+	if err != nil {
+		panic(err)
+	}
+
+	if !solved {
+		panic("team progress lost")
+	}
+
+	dbTasks, err = db.GetTasks(database)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(dbTasks) != 3 {
+		panic("removed task deleted or new task not added")
+	}
+}
+
+func TestMatchSlugs(*testing.T) {
+
+	current := []db.Task{
+		{ID: 1, Slug: "web1", Name: "task1", CategoryID: 1},
+		{ID: 2, Name: "task2", CategoryID: 1},
+	}
+
+	loaded := []db.Task{
+		{Slug: "web1", Name: "task1", CategoryID: 1},
+		{Slug: "crypto2", Name: "task2", CategoryID: 2},
+		{Slug: "web2", Name: "task2", CategoryID: 1},
+	}
+
+	slugs, err := matchSlugs(current, loaded)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(slugs) != 1 || slugs[2] != "web2" {
+		panic("slug of old task not matched")
+	}
+
+	current = append(current, db.Task{ID: 3, Name: "task2",
+		CategoryID: 1})
+
+	_, err = matchSlugs(current, loaded)
+	if err == nil {
+		panic("ambiguous slug matched")
+	}
+}
+
+// Test tasks created before slugs are updated instead of duplicated
+func TestSyncTasksWithoutSlug(*testing.T) {
+
+	database, err := db.InitDatabase(dbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	defer database.Close()
+
+	category := db.Category{Name: "web"}
+
+	err = db.AddCategory(database, &category)
+	if err != nil {
+		panic(err)
+	}
+
+	old := db.Task{Name: "task1", CategoryID: category.ID, Flag: "flag1"}
+
+	err = db.AddTask(database, &old)
+	if err != nil {
+		panic(err)
+	}
+
+	tasks := []config.Task{
+		{Slug: "web1", Name: "task1", Category: "web", Flag: "flag1"},
+	}
+
+	report, err := SyncTasks(database, tasks, false)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(report.Added) != 0 || len(report.Removed) != 0 ||
+		len(report.Updated) != 1 {
+		panic("invalid report for task without slug")
+	}
+
+	task, err := db.GetTask(database, old.ID)
+	if err != nil {
+		panic(err)
+	}
+
+	if task.Slug != "web1" {
+		panic("slug is not assigned")
+	}
+
+	orphan := db.Task{Name: "task2", CategoryID: category.ID}
+
+	err = db.AddTask(database, &orphan)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = SyncTasks(database, tasks, false)
+	if err == nil {
+		panic("sync with unmatched task without slug")
+	}
+}
@@ -0,0 +1,154 @@
+/**
+ * @file logging.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief structured logging
+ *
+ * Contain functions for setup leveled application log and audit log,
+ * log files are reopened after rotation
+ */
+
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// Audit is stream of security relevant events (auth, flags, alerts)
+var Audit = slog.Default().With("stream", "audit")
+
+// reopenFile is log file can be reopened after rotation
+type reopenFile struct {
+	lock sync.Mutex
+	path string
+	perm os.FileMode
+	file *os.File
+}
+
+func openFile(path string, perm os.FileMode) (f *reopenFile, err error) {
+	f = &reopenFile{path: path, perm: perm}
+	err = f.reopen()
+	return
+}
+
+func (f *reopenFile) reopen() (err error) {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND,
+		f.perm)
+	if err != nil {
+		return
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file = file
+	return
+}
+
+func (f *reopenFile) Write(p []byte) (n int, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.file.Write(p)
+}
+
+func (f *reopenFile) Close() (err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.file.Close()
+}
+
+var files []*reopenFile
+
+// NewHandler returns handler writes records in format (json or text)
+func NewHandler(w io.Writer, format string, level slog.Level) (
+	h slog.Handler, err error) {
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch format {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text", "":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		err = fmt.Errorf("Unknown log format %s", format)
+	}
+
+	return
+}
+
+// ParseLevel parse level of log (debug, info, warn or error)
+func ParseLevel(s string) (level slog.Level, err error) {
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	err = level.UnmarshalText([]byte(s))
+	return
+}
+
+// Setup redirect application log (including log package) and audit log
+// to files, audit log is readable only by owner
+func Setup(logPath, auditPath, format, level string) (err error) {
+
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return
+	}
+
+	// check format before open files
+	_, err = NewHandler(io.Discard, format, lvl)
+	if err != nil {
+		return
+	}
+
+	app, err := openFile(logPath, 0640)
+	if err != nil {
+		return
+	}
+
+	audit, err := openFile(auditPath, 0600)
+	if err != nil {
+		app.Close()
+		return
+	}
+
+	appHandler, _ := NewHandler(app, format, lvl)
+	auditHandler, _ := NewHandler(audit, format, slog.LevelInfo)
+
+	files = []*reopenFile{app, audit}
+
+	slog.SetDefault(slog.New(appHandler))
+	// log package is used only by libraries (e.g. errors of http server)
+	slog.SetLogLoggerLevel(slog.LevelError)
+	Audit = slog.New(auditHandler)
+
+	return
+}
+
+// Reopen reopen log files, e.g. after rotation
+func Reopen() (err error) {
+	for _, f := range files {
+		err = f.reopen()
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Close close log files
+func Close() {
+	for _, f := range files {
+		f.Close()
+	}
+}
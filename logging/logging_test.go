@@ -0,0 +1,116 @@
+/**
+ * @file logging_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief test structured logging
+ */
+
+package logging
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func bugOnMissing(path, s string) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+
+	if !strings.Contains(string(content), s) {
+		panic(errors.New("Missing '" + s + "' in " + path + ":\n" +
+			string(content)))
+	}
+}
+
+func TestSetup(*testing.T) {
+
+	logPath := "/tmp/henhouse-test.log"
+	auditPath := "/tmp/henhouse-test-audit.log"
+	rotatedPath := logPath + ".1"
+
+	for _, path := range []string{logPath, auditPath, rotatedPath} {
+		os.Remove(path)
+	}
+
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+	defer slog.SetLogLoggerLevel(slog.LevelInfo)
+
+	err := Setup(logPath, auditPath, "json", "warn")
+	if err != nil {
+		panic(err)
+	}
+	defer Close()
+
+	slog.Info("hidden by level")
+	slog.Error("Get tasks fail", "err", "pewpew")
+	log.Println("http: TLS handshake error")
+	Audit.Info("Auth", "team", 1)
+
+	bugOnMissing(logPath, `"msg":"Get tasks fail","err":"pewpew"`)
+	bugOnMissing(logPath, `"level":"ERROR","msg":"http: TLS handshake error`)
+	bugOnMissing(auditPath, `"msg":"Auth","team":1`)
+
+	err = os.Rename(logPath, rotatedPath)
+	if err != nil {
+		panic(err)
+	}
+
+	err = Reopen()
+	if err != nil {
+		panic(err)
+	}
+
+	slog.Warn("after rotation")
+
+	bugOnMissing(logPath, "after rotation")
+
+	content, err := ioutil.ReadFile(rotatedPath)
+	if err != nil {
+		panic(err)
+	}
+
+	if strings.Contains(string(content), "hidden by level") {
+		panic(errors.New("Message below level in log"))
+	}
+}
+
+func TestInvalidSetup(*testing.T) {
+
+	err := Setup("/tmp/henhouse-test.log", "/tmp/henhouse-test-audit.log",
+		"xml", "info")
+	if err == nil {
+		panic(errors.New("Setup with invalid format"))
+	}
+
+	_, err = ParseLevel("ololo")
+	if err == nil {
+		panic(errors.New("Invalid level parsed"))
+	}
+}
+
+func TestRequestID(*testing.T) {
+
+	id := NewRequestID()
+	if len(id) != 16 {
+		panic(errors.New("Invalid request id " + id))
+	}
+
+	ctx := WithRequestID(context.Background(), id)
+	if RequestID(ctx) != id {
+		panic(errors.New("Request id lost"))
+	}
+
+	if RequestID(context.Background()) != "" {
+		panic(errors.New("Request id without request"))
+	}
+}
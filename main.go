@@ -11,18 +11,20 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"log/slog"
 	"os"
+	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/jollheef/henhouse/config"
 	"github.com/jollheef/henhouse/db"
 	"github.com/jollheef/henhouse/game"
+	"github.com/jollheef/henhouse/logging"
+	"github.com/jollheef/henhouse/metrics"
 	"github.com/jollheef/henhouse/scoreboard"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
@@ -32,6 +34,8 @@ var (
 		"Path to configuration file.").Required().String()
 
 	dbReinit = kingpin.Flag("reinit", "Reinit database.").Bool()
+
+	checkOnly = kingpin.Flag("check", "Check configuration and exit.").Bool()
 )
 
 var (
@@ -43,27 +47,17 @@ var (
 	BuildTime string
 )
 
-func fillTranslateFallback(task * config.Task) {
-	if task.NameEn == "" {
-		task.NameEn = task.Name
-	}
-	if task.Name == "" {
-		task.Name = task.NameEn
-	}
-	if task.DescriptionEn == "" {
-		task.DescriptionEn = task.Description
-	}
-	if task.Description == "" {
-		task.Description = task.DescriptionEn
-	}
-	return
+// fatal log error and exit
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
 
 func reinitDatabase(database *sql.DB, cfg config.Config) (err error) {
-	log.Println("Reinit database")
+	slog.Info("Reinit database")
 
 	for _, team := range cfg.Teams {
-		log.Println("Add team", team.Name)
+		slog.Info("Add team", "team", team.Name)
 		err = db.AddTeam(database, &db.Team{
 			Name:  team.Name,
 			Desc:  team.Description,
@@ -75,94 +69,55 @@ func reinitDatabase(database *sql.DB, cfg config.Config) (err error) {
 		}
 	}
 
-	entries, err := ioutil.ReadDir(cfg.TaskDir)
+	tasks, err := config.ReadTaskDir(cfg.TaskDir)
 	if err != nil {
 		return
 	}
 
-	var categories []db.Category
-
-	for _, entry := range entries {
-
-		if entry.IsDir() {
-			continue
-		}
-
-		var content []byte
-		content, err = ioutil.ReadFile(cfg.TaskDir + "/" +
-			entry.Name())
-		if err != nil {
-			return
-		}
-
-		var task config.Task
-		task, err = config.ParseXMLTask(content)
-		if err != nil {
-			return
-		}
-
-		var finded bool
-		var taskCategory db.Category
-		for _, cat := range categories {
-			if cat.Name == task.Category {
-				finded = true
-				taskCategory = cat
-				break
-			}
-		}
-
-		if !finded {
-			taskCategory.Name = task.Category
-
-			err = db.AddCategory(database, &taskCategory)
-			if err != nil {
-				return
-			}
-
-			categories = append(categories, taskCategory)
-
-			log.Println("Add category", taskCategory.Name)
-		}
-
-		fillTranslateFallback(&task)
-
-		err = db.AddTask(database, &db.Task{
-			Name:          task.Name,
-			Desc:          task.Description,
-			NameEn:        task.NameEn,
-			DescEn:        task.DescriptionEn,
-			Tags:          task.Tags,
-			CategoryID:    taskCategory.ID,
-			Level:         task.Level,
-			Flag:          task.Flag,
-			Price:         500,   // TODO support non-shared task
-			Shared:        true,  // TODO support non-shared task
-			MaxSharePrice: 500,   // TODO support value from xml
-			MinSharePrice: 100,   // TODO support value from xml
-			Opened:        false, // by default task is closed
-			Author:        task.Author,
-			ForceClosed:   task.ForceClosed,
-		})
-
-		log.Println("Add task", task.Name)
-
-		if err != nil {
-			return
-		}
+	_, err = game.SyncTasks(database, tasks, false)
+	if err != nil {
+		return
 	}
 
 	return
 }
 
-func checkTaskPrices(cfg *config.Config)(err error){
-	if cfg.TaskPrice.P200 == 0 || cfg.TaskPrice.P300 == 0 ||
-		cfg.TaskPrice.P400 == 0 || cfg.TaskPrice.P500 == 0 {
-		err = errors.New("Error: Task price not setted")
+func checkConfig(cfg config.Config) (ok bool) {
+	errs := cfg.Validate()
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, "Config:", err)
 	}
-	return
+	return len(errs) == 0
 }
 
-func initGame(database *sql.DB, cfg config.Config) (err error) {
+func setServerTimeouts(cfg config.Config) {
+
+	timeouts := []struct {
+		name  string
+		value time.Duration
+		set   *time.Duration
+	}{
+		{"Read", cfg.Scoreboard.ReadTimeout.Duration,
+			&scoreboard.ReadTimeout},
+		{"Write", cfg.Scoreboard.WriteTimeout.Duration,
+			&scoreboard.WriteTimeout},
+		{"Idle", cfg.Scoreboard.IdleTimeout.Duration,
+			&scoreboard.IdleTimeout},
+		{"Shutdown", cfg.Scoreboard.ShutdownTimeout.Duration,
+			&scoreboard.ShutdownTimeout},
+	}
+
+	for _, t := range timeouts {
+		if t.value != 0 {
+			*t.set = t.value
+		}
+		slog.Info("Set server timeout", "name", t.name,
+			"timeout", *t.set)
+	}
+}
+
+func initGame(ctx context.Context, database *sql.DB,
+	cfg config.Config) (err error) {
 
 	var teamBase float64
 
@@ -171,91 +126,89 @@ func initGame(database *sql.DB, cfg config.Config) (err error) {
 		if err != nil {
 			return
 		}
-		log.Println("Use teams amount based on session counter")
+		slog.Info("Use teams amount based on session counter")
 	} else if cfg.TaskPrice.UseTeamsBase {
 		teamBase = float64(cfg.TaskPrice.TeamsBase)
-		log.Println("Set teams base to", cfg.TaskPrice.TeamsBase)
+		slog.Info("Set teams base", "teams", cfg.TaskPrice.TeamsBase)
 	} else {
 		teamBase = float64(len(cfg.Teams))
-		log.Println("Use teams amount as teams base")
+		slog.Info("Use teams amount as teams base")
 	}
 
-	log.Println("Start game at", cfg.Game.Start.Time)
-	log.Println("End game at", cfg.Game.End.Time)
-	g, err := game.NewGame(database, cfg.Game.Start.Time,
-		cfg.Game.End.Time, teamBase)
+	state, err := game.InitState(database, cfg.Game.Start.Time,
+		cfg.Game.End.Time, cfg.Game.Freeze.Time)
+	if err != nil {
+		return
+	}
+
+	slog.Info("Game timing", "start", state.Start, "end", state.End)
+	if !state.Freeze.IsZero() {
+		slog.Info("Freeze scoreboard", "at", state.Freeze)
+	}
+
+	g, err := game.NewGame(database, state.Start, state.End, teamBase)
 	if err != nil {
 		return
 	}
 
 	if cfg.TaskPrice.UseNonLinear {
-		go g.TeamsBaseUpdater(database,
+		go g.TeamsBaseUpdater(ctx, database,
 			cfg.Scoreboard.RecalcTimeout.Duration)
 	}
 
-	err = checkTaskPrices(&cfg)
-	if err != nil{
-		return
+	if cfg.Task.PerTeam {
+		slog.Info("Open tasks for each team separately")
 	}
 
-	fmt := "Set task price %d if solved less than %d%%\n"
-	log.Printf(fmt, 200, cfg.TaskPrice.P200)
-	log.Printf(fmt, 300, cfg.TaskPrice.P300)
-	log.Printf(fmt, 400, cfg.TaskPrice.P400)
-	log.Printf(fmt, 500, cfg.TaskPrice.P500)
+	g.PerTeam = cfg.Task.PerTeam
 
-	g.SetTaskPrice(cfg.TaskPrice.P500, cfg.TaskPrice.P400,
-		cfg.TaskPrice.P300, cfg.TaskPrice.P200)
+	applyConfig(&g, cfg)
 
-	log.Println("Set task open timeout to", cfg.Task.OpenTimeout.Duration)
-	g.OpenTimeout = cfg.Task.OpenTimeout.Duration
+	go g.Run(ctx)
 
-	if cfg.Task.AutoOpen {
-		log.Println("Auto open tasks after",
-			cfg.Task.AutoOpenTimeout.Duration)
-	} else {
-		log.Println("Auto open tasks disabled")
+	if cfg.Task.AutoSync {
+		slog.Info("Sync tasks", "dir", cfg.TaskDir,
+			"timeout", cfg.Task.AutoSyncTimeout.Duration)
+		go game.TaskDirWatcher(ctx, database, cfg.TaskDir,
+			cfg.Task.AutoSyncTimeout.Duration)
 	}
 
-	g.AutoOpen = cfg.Task.AutoOpen
-	g.AutoOpenTimeout = cfg.Task.AutoOpenTimeout.Duration
-
-	go g.Run()
+	scoreboard.Location = cfg.Game.Location
+	slog.Info("Set timezone", "location", scoreboard.Location.String())
 
-	infoD := cfg.WebsocketTimeout.Info.Duration
-	if infoD != 0 {
-		scoreboard.InfoTimeout = infoD
+	if cfg.Metrics.Addr != "" {
+		slog.Info("Serve metrics", "addr", cfg.Metrics.Addr)
+		go func() {
+			err := metrics.ListenAndServe(ctx, cfg.Metrics.Addr)
+			if err != nil {
+				slog.Error("Metrics server fail", "err", err)
+			}
+		}()
 	}
-	log.Println("Update info timeout:", scoreboard.InfoTimeout)
 
-	scoreboardD := cfg.WebsocketTimeout.Scoreboard.Duration
-	if scoreboardD != 0 {
-		scoreboard.ScoreboardTimeout = scoreboardD
-	}
-	log.Println("Update scoreboard timeout:", scoreboard.ScoreboardTimeout)
+	go reloadOnSignal(&g, cfg)
 
-	tasksD := cfg.WebsocketTimeout.Tasks.Duration
-	if tasksD != 0 {
-		scoreboard.TasksTimeout = tasksD
-	}
-	log.Println("Update tasks timeout:", scoreboard.TasksTimeout)
+	setServerTimeouts(cfg)
+
+	scoreboard.TLSCertFile = cfg.Scoreboard.TLSCert
+	scoreboard.TLSKeyFile = cfg.Scoreboard.TLSKey
+	scoreboard.RedirectAddr = cfg.Scoreboard.RedirectAddr
 
-	flagSendD := cfg.Flag.SendTimeout.Duration
-	if flagSendD != 0 {
-		scoreboard.FlagTimeout = flagSendD
+	err = scoreboard.SetTrustedProxies(cfg.Scoreboard.TrustedProxies)
+	if err != nil {
+		return
 	}
-	log.Println("Flag timeout:", scoreboard.FlagTimeout)
 
-	scoreboardRecalcD := cfg.Scoreboard.RecalcTimeout.Duration
-	if scoreboardRecalcD != 0 {
-		scoreboard.ScoreboardRecalcTimeout = scoreboardRecalcD
+	err = scoreboard.SetProxyHeader(cfg.Scoreboard.ProxyHeader)
+	if err != nil {
+		return
 	}
 
-	log.Println("Score recalc timeout:", scoreboard.ScoreboardRecalcTimeout)
+	scoreboard.TaskDir = cfg.TaskDir
 
-	log.Println("Use html files from", cfg.Scoreboard.WwwPath)
-	log.Println("Listen at", cfg.Scoreboard.Addr)
-	err = scoreboard.Scoreboard(database, &g,
+	slog.Info("Use html files", "path", cfg.Scoreboard.WwwPath)
+	slog.Info("Listen", "addr", cfg.Scoreboard.Addr)
+	err = scoreboard.Scoreboard(ctx, database, &g,
 		cfg.Scoreboard.WwwPath,
 		cfg.Scoreboard.TemplatePath,
 		cfg.Scoreboard.Addr,
@@ -281,26 +234,36 @@ func main() {
 
 	cfg, err := config.ReadConfig(*configPath)
 	if err != nil {
-		log.Fatalln("Cannot open config:", err)
+		fatal("Cannot open config", "err", err)
 	}
 
-	logFile, err := os.OpenFile(cfg.LogFile,
-		os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if !checkConfig(cfg) {
+		os.Exit(1)
+	}
+
+	if *checkOnly {
+		fmt.Println("Config OK")
+		return
+	}
+
+	err = logging.Setup(cfg.LogFile, cfg.AuditLogFile, cfg.LogFormat,
+		cfg.LogLevel)
 	if err != nil {
-		log.Fatalln("Cannot open file:", err)
+		fatal("Cannot open log", "err", err)
 	}
-	defer logFile.Close()
-	log.SetOutput(logFile)
+	defer logging.Close()
+
+	go reopenLogsOnSignal()
 
-	log.Println(version)
+	slog.Info("Start", "version", version)
 
 	var rlim syscall.Rlimit
 	err = syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlim)
 	if err != nil {
-		log.Fatalln("Getrlimit fail:", err)
+		fatal("Getrlimit fail", "err", err)
 	}
 
-	log.Println("RLIMIT_NOFILE CUR:", rlim.Cur, "MAX:", rlim.Max)
+	slog.Info("RLIMIT_NOFILE", "cur", rlim.Cur, "max", rlim.Max)
 
 	var database *sql.DB
 
@@ -308,42 +271,49 @@ func main() {
 
 		if cfg.Database.SafeReinit {
 			if time.Now().After(cfg.Game.Start.Time) {
-				log.Fatalln("Reinit after start not allowed")
+				fatal("Reinit after start not allowed")
 			}
 		}
 
 		database, err = db.InitDatabase(cfg.Database.Connection)
 		if err != nil {
-			log.Fatalln("Error:", err)
+			fatal("Error", "err", err)
 		}
 
 		err = db.CleanDatabase(database)
 		if err != nil {
-			log.Fatalln("Error:", err)
+			fatal("Error", "err", err)
 		}
 
 		defer database.Close()
 
 		err = reinitDatabase(database, cfg)
 		if err != nil {
-			log.Fatalln("Error:", err)
+			fatal("Error", "err", err)
 		}
 
 	} else {
 
 		database, err = db.OpenDatabase(cfg.Database.Connection)
 		if err != nil {
-			log.Fatalln("Error:", err)
+			fatal("Error", "err", err)
 		}
 
 		defer database.Close()
 	}
 
-	log.Println("Set max db connections to", cfg.Database.MaxConnections)
+	slog.Info("Set max db connections",
+		"connections", cfg.Database.MaxConnections)
 	database.SetMaxOpenConns(cfg.Database.MaxConnections)
 
-	err = initGame(database, cfg)
+	ctx, stop := signal.NotifyContext(context.Background(),
+		syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	err = initGame(ctx, database, cfg)
 	if err != nil {
-		log.Fatalln("Error:", err)
+		fatal("Error", "err", err)
 	}
+
+	slog.Info("Stopped")
 }
@@ -0,0 +1,256 @@
+/**
+ * @file metrics.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief prometheus metrics
+ *
+ * Contain counters, gauges and histograms exported in prometheus text
+ * format
+ */
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type metric interface {
+	write(w io.Writer)
+}
+
+var (
+	registry     []metric
+	registryLock sync.Mutex
+)
+
+func register(m metric) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	registry = append(registry, m)
+}
+
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).
+		Replace(value)
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	var pairs []string
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name,
+			escape(values[i])))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return fmt.Sprint(v)
+}
+
+// vec is set of values of metric with different label values
+type vec struct {
+	name, help, typ string
+	labels          []string
+
+	lock   sync.Mutex
+	values map[string]float64
+	// label values by key of values
+	labelValues map[string][]string
+}
+
+func newVec(name, help, typ string, labels []string) (v *vec) {
+	v = &vec{name: name, help: help, typ: typ, labels: labels,
+		values:      make(map[string]float64),
+		labelValues: make(map[string][]string)}
+	register(v)
+	return
+}
+
+func (v *vec) update(labelValues []string, f func(old float64) float64) {
+	if len(labelValues) != len(v.labels) {
+		panic("metrics: invalid amount of label values for " + v.name)
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.values[key] = f(v.values[key])
+	v.labelValues[key] = labelValues
+}
+
+func (v *vec) add(delta float64, labelValues []string) {
+	v.update(labelValues, func(old float64) float64 { return old + delta })
+}
+
+func (v *vec) set(value float64, labelValues []string) {
+	v.update(labelValues, func(float64) float64 { return value })
+}
+
+func (v *vec) write(w io.Writer) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, v.help,
+		v.name, v.typ)
+
+	if len(v.labels) == 0 && len(v.values) == 0 {
+		fmt.Fprintf(w, "%s 0\n", v.name)
+		return
+	}
+
+	var keys []string
+	for key := range v.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s%s %s\n", v.name,
+			formatLabels(v.labels, v.labelValues[key]),
+			formatValue(v.values[key]))
+	}
+}
+
+// Counter is metric can only increase
+type Counter struct {
+	vec *vec
+}
+
+// NewCounter register counter with labels
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{newVec(name, help, "counter", labels)}
+}
+
+// Inc increment counter with label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.vec.add(1, labelValues)
+}
+
+// Gauge is metric can increase and decrease
+type Gauge struct {
+	vec *vec
+}
+
+// NewGauge register gauge with labels
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{newVec(name, help, "gauge", labels)}
+}
+
+// Inc increment gauge with label values
+func (g *Gauge) Inc(labelValues ...string) {
+	g.vec.add(1, labelValues)
+}
+
+// Dec decrement gauge with label values
+func (g *Gauge) Dec(labelValues ...string) {
+	g.vec.add(-1, labelValues)
+}
+
+// Set set value of gauge with label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.vec.set(value, labelValues)
+}
+
+type gaugeFunc struct {
+	name, help string
+	value      func() float64
+}
+
+func (g gaugeFunc) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", g.name,
+		g.help, g.name, g.name, formatValue(g.value()))
+}
+
+// NewGaugeFunc register gauge calculated on each scrape
+func NewGaugeFunc(name, help string, value func() float64) {
+	register(gaugeFunc{name, help, value})
+}
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	name, help string
+	buckets    []float64
+
+	lock   sync.Mutex
+	counts []uint64 // cumulative
+	sum    float64
+	count  uint64
+}
+
+// DefaultBuckets fit latency in seconds from 1ms to 10s
+var DefaultBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1,
+	2.5, 5, 10}
+
+// NewHistogram register histogram with upper bounds of buckets
+func NewHistogram(name, help string, buckets []float64) (h *Histogram) {
+	h = &Histogram{name: name, help: help, buckets: buckets,
+		counts: make([]uint64, len(buckets))}
+	register(h)
+	return
+}
+
+// Observe add value to histogram
+func (h *Histogram) Observe(value float64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help,
+		h.name)
+
+	for i, bound := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.name,
+			formatValue(bound), h.counts[i])
+	}
+
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", h.name, formatValue(h.sum))
+	fmt.Fprintf(w, "%s_count %d\n", h.name, h.count)
+}
+
+// WriteTo write all metrics in prometheus text format
+func WriteTo(w io.Writer) {
+	registryLock.Lock()
+	metrics := append([]metric{}, registry...)
+	registryLock.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+func init() {
+	NewGaugeFunc("go_goroutines", "Number of goroutines.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+}
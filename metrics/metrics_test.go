@@ -0,0 +1,68 @@
+/**
+ * @file metrics_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief test metrics
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func bugOnMissing(output, line string) {
+	if !strings.Contains(output, line+"\n") {
+		panic("Missing '" + line + "' in:\n" + output)
+	}
+}
+
+func TestMetrics(*testing.T) {
+
+	c := NewCounter("test_submissions_total", "Submissions.", "verdict")
+	c.Inc("solved")
+	c.Inc("wrong")
+	c.Inc("wrong")
+
+	g := NewGauge("test_websockets", "Websockets.", "channel")
+	g.Inc("info")
+	g.Inc("info")
+	g.Dec("info")
+	g.Set(5, "tasks")
+
+	h := NewHistogram("test_seconds", "Latency.", []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(2)
+
+	var buf bytes.Buffer
+	WriteTo(&buf)
+	output := buf.String()
+
+	bugOnMissing(output, "# TYPE test_submissions_total counter")
+	bugOnMissing(output, `test_submissions_total{verdict="solved"} 1`)
+	bugOnMissing(output, `test_submissions_total{verdict="wrong"} 2`)
+	bugOnMissing(output, `test_websockets{channel="info"} 1`)
+	bugOnMissing(output, `test_websockets{channel="tasks"} 5`)
+	bugOnMissing(output, `test_seconds_bucket{le="0.1"} 1`)
+	bugOnMissing(output, `test_seconds_bucket{le="1"} 2`)
+	bugOnMissing(output, `test_seconds_bucket{le="+Inf"} 3`)
+	bugOnMissing(output, `test_seconds_sum 2.55`)
+	bugOnMissing(output, `test_seconds_count 3`)
+	bugOnMissing(output, "# TYPE go_goroutines gauge")
+}
+
+func TestEscapeLabel(*testing.T) {
+
+	c := NewCounter("test_escape_total", "Escape.", "task")
+	c.Inc("a\"b\\c\nd")
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	bugOnMissing(w.Body.String(), `test_escape_total{task="a\"b\\c\nd"} 1`)
+}
@@ -0,0 +1,48 @@
+/**
+ * @file server.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief metrics endpoint
+ *
+ * Contain admin http server with /metrics endpoint
+ */
+
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	WriteTo(w)
+}
+
+// ListenAndServe serve /metrics at admin address until cancel of context
+func ListenAndServe(ctx context.Context, addr string) (err error) {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", handler)
+
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	err = server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		err = nil
+	}
+
+	return
+}
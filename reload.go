@@ -0,0 +1,241 @@
+/**
+ * @file reload.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief reload configuration
+ *
+ * Contain functions for apply configuration to running daemon on SIGHUP
+ * and reopen logs on SIGUSR1
+ */
+
+package main
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+
+	"github.com/jollheef/henhouse/config"
+	"github.com/jollheef/henhouse/game"
+	"github.com/jollheef/henhouse/logging"
+	"github.com/jollheef/henhouse/scoreboard"
+)
+
+// applyConfig set values can be changed without restart
+func applyConfig(g *game.Game, cfg config.Config) {
+
+	slog.Info("Set task price", "p200", cfg.TaskPrice.P200,
+		"p300", cfg.TaskPrice.P300, "p400", cfg.TaskPrice.P400,
+		"p500", cfg.TaskPrice.P500)
+
+	g.SetTaskPrice(cfg.TaskPrice.P500, cfg.TaskPrice.P400,
+		cfg.TaskPrice.P300, cfg.TaskPrice.P200)
+
+	slog.Info("Set task open timeout",
+		"timeout", cfg.Task.OpenTimeout.Duration)
+
+	if cfg.Task.AutoOpen {
+		slog.Info("Auto open tasks",
+			"timeout", cfg.Task.AutoOpenTimeout.Duration)
+	} else {
+		slog.Info("Auto open tasks disabled")
+	}
+
+	if cfg.Flag.Penalty != 0 {
+		slog.Info("Set penalty for wrong flag",
+			"penalty", cfg.Flag.Penalty, "cap", cfg.Flag.PenaltyCap)
+	}
+
+	// game is running, so settings are replaced at once
+	g.UpdateSettings(func(s *game.Settings) {
+		s.OpenTimeout = cfg.Task.OpenTimeout.Duration
+		s.AutoOpen = cfg.Task.AutoOpen
+		s.AutoOpenTimeout = cfg.Task.AutoOpenTimeout.Duration
+		s.Penalty = cfg.Flag.Penalty
+		s.PenaltyCap = cfg.Flag.PenaltyCap
+	})
+
+	t := scoreboard.GetTimeouts()
+
+	infoD := cfg.WebsocketTimeout.Info.Duration
+	if infoD != 0 {
+		t.Info = infoD
+	}
+	slog.Info("Update info timeout", "timeout", t.Info)
+
+	scoreboardD := cfg.WebsocketTimeout.Scoreboard.Duration
+	if scoreboardD != 0 {
+		t.Scoreboard = scoreboardD
+	}
+	slog.Info("Update scoreboard timeout", "timeout", t.Scoreboard)
+
+	tasksD := cfg.WebsocketTimeout.Tasks.Duration
+	if tasksD != 0 {
+		t.Tasks = tasksD
+	}
+	slog.Info("Update tasks timeout", "timeout", t.Tasks)
+
+	flagSendD := cfg.Flag.SendTimeout.Duration
+	if flagSendD != 0 {
+		t.Flag = flagSendD
+	}
+	slog.Info("Flag timeout", "timeout", t.Flag)
+
+	scoreboardRecalcD := cfg.Scoreboard.RecalcTimeout.Duration
+	if scoreboardRecalcD != 0 {
+		t.Recalc = scoreboardRecalcD
+	}
+	slog.Info("Score recalc timeout", "timeout", t.Recalc)
+
+	scoreboard.SetTimeouts(t)
+}
+
+// restartRequired returns settings changed in configuration, but can not
+// be applied without restart
+func restartRequired(old, cfg config.Config) (changed []string) {
+
+	settings := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"log_file", old.LogFile, cfg.LogFile},
+		{"audit_log_file", old.AuditLogFile, cfg.AuditLogFile},
+		{"log_level", old.LogLevel, cfg.LogLevel},
+		{"log_format", old.LogFormat, cfg.LogFormat},
+		{"task_dir", old.TaskDir, cfg.TaskDir},
+		{"Database", old.Database, cfg.Database},
+		{"Scoreboard.www_path", old.Scoreboard.WwwPath,
+			cfg.Scoreboard.WwwPath},
+		{"Scoreboard.template_path", old.Scoreboard.TemplatePath,
+			cfg.Scoreboard.TemplatePath},
+		{"Scoreboard.addr", old.Scoreboard.Addr, cfg.Scoreboard.Addr},
+		{"Scoreboard.under_proxy", old.Scoreboard.UnderProxy,
+			cfg.Scoreboard.UnderProxy},
+		{"Scoreboard.trusted_proxies", old.Scoreboard.TrustedProxies,
+			cfg.Scoreboard.TrustedProxies},
+		{"Scoreboard.proxy_header", old.Scoreboard.ProxyHeader,
+			cfg.Scoreboard.ProxyHeader},
+		{"Scoreboard.read_timeout", old.Scoreboard.ReadTimeout,
+			cfg.Scoreboard.ReadTimeout},
+		{"Scoreboard.write_timeout", old.Scoreboard.WriteTimeout,
+			cfg.Scoreboard.WriteTimeout},
+		{"Scoreboard.idle_timeout", old.Scoreboard.IdleTimeout,
+			cfg.Scoreboard.IdleTimeout},
+		{"Scoreboard.shutdown_timeout", old.Scoreboard.ShutdownTimeout,
+			cfg.Scoreboard.ShutdownTimeout},
+		{"Scoreboard.tls_cert", old.Scoreboard.TLSCert,
+			cfg.Scoreboard.TLSCert},
+		{"Scoreboard.tls_key", old.Scoreboard.TLSKey,
+			cfg.Scoreboard.TLSKey},
+		{"Scoreboard.redirect_addr", old.Scoreboard.RedirectAddr,
+			cfg.Scoreboard.RedirectAddr},
+		{"Metrics.addr", old.Metrics.Addr, cfg.Metrics.Addr},
+		{"TaskPrice.use_non_linear", old.TaskPrice.UseNonLinear,
+			cfg.TaskPrice.UseNonLinear},
+		{"TaskPrice.use_teams_base", old.TaskPrice.UseTeamsBase,
+			cfg.TaskPrice.UseTeamsBase},
+		{"TaskPrice.teams_base", old.TaskPrice.TeamsBase,
+			cfg.TaskPrice.TeamsBase},
+		{"Game.timezone", old.Game.Timezone, cfg.Game.Timezone},
+		{"Task.per_team", old.Task.PerTeam, cfg.Task.PerTeam},
+		{"Task.auto_sync", old.Task.AutoSync, cfg.Task.AutoSync},
+		{"Task.auto_sync_timeout", old.Task.AutoSyncTimeout,
+			cfg.Task.AutoSyncTimeout},
+		{"Teams", old.Teams, cfg.Teams},
+	}
+
+	for _, s := range settings {
+		if !reflect.DeepEqual(s.old, s.new) {
+			changed = append(changed, s.name)
+		}
+	}
+
+	return
+}
+
+// gameTimeChanged returns changed timing of game, it is used only for new
+// game, timing of existing game is changed by henhousectl game
+func gameTimeChanged(old, cfg config.Config) (changed []string) {
+
+	if !old.Game.Start.Equal(cfg.Game.Start.Time) {
+		changed = append(changed, "Game.start")
+	}
+
+	if !old.Game.End.Equal(cfg.Game.End.Time) {
+		changed = append(changed, "Game.end")
+	}
+
+	if !old.Game.Freeze.Equal(cfg.Game.Freeze.Time) {
+		changed = append(changed, "Game.freeze")
+	}
+
+	return
+}
+
+// reloadOnSignal reread configuration on SIGHUP
+func reloadOnSignal(g *game.Game, cfg config.Config) {
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for range hup {
+		slog.Info("Reload config", "path", *configPath)
+
+		newCfg, err := config.ReadConfig(*configPath)
+		if err != nil {
+			slog.Error("Cannot open config", "err", err)
+			continue
+		}
+
+		errs := newCfg.Validate()
+		for _, err := range errs {
+			slog.Error("Config", "err", err)
+		}
+
+		if len(errs) != 0 {
+			slog.Error("Config is invalid, reload skipped")
+			continue
+		}
+
+		applyConfig(g, newCfg)
+		scoreboard.ClearTemplateCache()
+
+		err = scoreboard.ReloadCertificate()
+		if err != nil {
+			slog.Error("Reload certificate fail", "err", err)
+		}
+
+		for _, name := range restartRequired(cfg, newCfg) {
+			slog.Warn("Setting changed, restart required to apply",
+				"setting", name)
+		}
+
+		for _, name := range gameTimeChanged(cfg, newCfg) {
+			slog.Warn("Setting changed, but it is used only for new "+
+				"game, use henhousectl game extend or game freeze",
+				"setting", name)
+		}
+
+		// compare next reload with applied configuration
+		cfg = newCfg
+	}
+}
+
+// reopenLogsOnSignal reopen log files on SIGUSR1 after rotation
+func reopenLogsOnSignal() {
+
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+
+	for range usr1 {
+		err := logging.Reopen()
+		if err != nil {
+			slog.Error("Reopen logs fail", "err", err)
+			continue
+		}
+		slog.Info("Logs reopened")
+	}
+}
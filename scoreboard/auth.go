@@ -10,13 +10,15 @@ package scoreboard
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
-	"log"
 	"net/http"
 
 	"github.com/gorilla/context"
 	"github.com/jollheef/henhouse/db"
+	"github.com/jollheef/henhouse/metrics"
 )
 
 const (
@@ -26,17 +28,14 @@ const (
 
 var authEnabled = true
 
-var underProxy bool
-
-func getClientAddr(r *http.Request) (clientAddr string) {
-    
-    if underProxy {
-        clientAddr = r.Header.Get("x-real-ip")
-    } else {
-        clientAddr = r.RemoteAddr
-    }
-              
-    return
+var sessionsCreated = metrics.NewCounter("henhouse_sessions_created_total",
+	"Created sessions.")
+
+// redactToken returns short hash of access token, enough to correlate
+// records of log with hash of token in database
+func redactToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:4])
 }
 
 func genSession() (s string, err error) {
@@ -73,23 +72,27 @@ func getSessionTeamID(database *sql.DB, r *http.Request) (teamID int,
 }
 
 func setSessionTeamID(database *sql.DB, w http.ResponseWriter,
-	teamID int) (err error) {
+	r *http.Request, teamID int) (err error) {
 
 	session, err := genSession()
 	if err != nil {
 		return
 	}
 
-	cookie := http.Cookie{Name: sessionCookieName, Value: session}
+	cookie := http.Cookie{Name: sessionCookieName, Value: session,
+		Secure: r.TLS != nil}
 
 	err = db.AddSession(database, &db.Session{
 		TeamID:  teamID,
 		Session: session,
+		Addr:    getClientAddr(r),
 	})
 	if err != nil {
 		return
 	}
 
+	sessionsCreated.Inc()
+
 	http.SetCookie(w, &cookie)
 
 	return
@@ -102,11 +105,31 @@ func getTeamID(r *http.Request) int {
 	return 0
 }
 
+// bannedError write forbidden with reason if team is banned
+func bannedError(database *sql.DB, w http.ResponseWriter,
+	teamID int) (banned bool) {
+
+	team, err := db.GetTeam(database, teamID)
+	if err != nil || !team.Banned {
+		return
+	}
+
+	msg := "Team is banned"
+	if team.Reason != "" {
+		msg += ": " + team.Reason
+	}
+
+	http.Error(w, msg, http.StatusForbidden)
+	return true
+}
+
 func authorized(database *sql.DB, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		teamID, err := getSessionTeamID(database, r)
 		if err != nil && authEnabled {
 			http.Redirect(w, r, "/auth.html", 307)
+		} else if err == nil && bannedError(database, w, teamID) {
+			return
 		} else {
 			context.Set(r, contextTeamIDName, teamID)
 			context.ClearHandler(next).ServeHTTP(w, r)
@@ -128,27 +151,35 @@ func authHandler(database *sql.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("auth ip: %s, access_token: %s", getClientAddr(r), token)
+	audit := auditLog(r)
+
+	audit.Info("Auth attempt", "token_hash", redactToken(token))
 
 	teamID, err := db.GetTeamIDByToken(database, token)
 	if err != nil {
+		audit.Warn("Auth fail", "err", err)
 		w.WriteHeader(http.StatusUnauthorized)
 		tmpl, err := getTmpl("auth_error")
 		if err != nil {
-			log.Println(err)
+			requestLog(r).Error("Get template fail", "err", err)
 			return
 		}
 		fmt.Fprint(w, tmpl)
 		return
 	}
 
-	err = setSessionTeamID(database, w, teamID)
+	if bannedError(database, w, teamID) {
+		audit.Warn("Banned team auth", "team", teamID)
+		return
+	}
+
+	err = setSessionTeamID(database, w, r, teamID)
 	if err != nil {
-		log.Println("Set session id fail:", err)
+		requestLog(r).Error("Set session id fail", "err", err)
 		return
 	}
 
-	log.Printf("Success auth ip: %s team ID: %d", getClientAddr(r), teamID)
+	audit.Info("Auth success", "team", teamID)
 
 	// Success auth
 	http.Redirect(w, r, "/", 303)
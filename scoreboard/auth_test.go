@@ -49,10 +49,11 @@ func TestSessionTeamID(*testing.T) {
 	defer database.Close()
 
 	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "http://localhost/auth.php", nil)
 
 	realTeamID := 1
 
-	err := setSessionTeamID(database, w, realTeamID)
+	err := setSessionTeamID(database, w, r, realTeamID)
 	if err != nil {
 		panic(err)
 	}
@@ -155,3 +156,33 @@ func TestLogonLogout(*testing.T) {
 		panic("logout does not remove cookies")
 	}
 }
+
+func TestAuthHandlerBanned(*testing.T) {
+
+	database := testDB()
+	defer database.Close()
+
+	teams, err := db.GetTeams(database)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, team := range teams {
+		err = db.SetBanned(database, team.ID, true, false, "test")
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	r := httptest.NewRequest("POST", "http://localhost", nil)
+	w := httptest.NewRecorder()
+
+	r.Form = url.Values{}
+	r.Form.Set("token", "l")
+
+	authHandler(database, w, r)
+
+	if w.Code != http.StatusForbidden {
+		panic("wrong status")
+	}
+}
@@ -10,6 +10,11 @@ package scoreboard
 
 import (
 	"fmt"
+	"html"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/jollheef/henhouse/game"
 )
@@ -23,6 +28,14 @@ func taskSolvedBy(task game.TaskInfo, teamID int) bool {
 	return false
 }
 
+// taskKey returns slug of task or id if task has no slug
+func taskKey(task game.TaskInfo) string {
+	if task.Slug != "" {
+		return task.Slug
+	}
+	return fmt.Sprintf("%d", task.ID)
+}
+
 func taskToHTML(teamID int, task game.TaskInfo,
 	ru bool) (html string) {
 
@@ -37,9 +50,9 @@ func taskToHTML(teamID int, task game.TaskInfo,
 	}
 
 	if task.Opened {
-		html = fmt.Sprintf(`<a href="/task?id=%d" `+
+		html = fmt.Sprintf(`<a href="/task?id=%s" `+
 			`class="task_block task_block-%s">`,
-			task.ID, buttonClass)
+			url.QueryEscape(taskKey(task)), buttonClass)
 	} else {
 		html = fmt.Sprintf(`<a class="task_block task_block-%s">`, buttonClass)
 	}
@@ -57,9 +70,52 @@ func taskToHTML(teamID int, task game.TaskInfo,
 	  </div>
 	  <div class="task_block-body">%d</div>
 	  <div class="task_block-footer">
-	    <span class="task_block-tags">%s</span>
+	    <span class="task_block-tags">%s</span>%s
 	  </div>
-	</a>`, name, task.Price, task.Tags)
+	</a>`, name, task.Price, task.Tags, releaseToHTML(task))
+
+	return
+}
+
+// releaseToHTML returns upcoming release time of closed task
+func releaseToHTML(task game.TaskInfo) string {
+
+	now := time.Now()
+
+	if task.Opened || task.ForceClosed || task.OpenAt.IsZero() ||
+		task.OpenAt.Before(now) {
+		return ""
+	}
+
+	layout := "15:04"
+	if task.OpenAt.Sub(now) > 24*time.Hour {
+		layout = "02.01 15:04"
+	}
+
+	return fmt.Sprintf(`<span class="task_block-release">Opens at %s</span>`,
+		task.OpenAt.In(Location).Format(layout))
+}
+
+// taskExtrasToHTML returns hints and links to files of task
+func taskExtrasToHTML(task game.TaskInfo) (s string) {
+
+	if task.Hints != "" {
+		s += fmt.Sprintf(`<div class="task_hints">%s</div>`,
+			strings.ReplaceAll(html.EscapeString(task.Hints), "\n",
+				"<br>"))
+	}
+
+	if len(task.Files) == 0 {
+		return
+	}
+
+	s += `<div class="task_files">`
+	for _, file := range task.Files {
+		s += fmt.Sprintf(`<a href="/file?id=%s&name=%s">%s</a><br>`,
+			url.QueryEscape(taskKey(task)), url.QueryEscape(file),
+			html.EscapeString(filepath.Base(file)))
+	}
+	s += `</div>`
 
 	return
 }
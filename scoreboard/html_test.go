@@ -9,7 +9,9 @@
 package scoreboard
 
 import (
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/jollheef/henhouse/game"
 )
@@ -20,6 +22,30 @@ func TestTaskToHTML(*testing.T) {
 
 	html = taskToHTML(1, game.TaskInfo{Opened: true}, true)
 	testNotMatch("closed", html)
+
+	openAt := time.Now().Add(time.Hour)
+
+	html = taskToHTML(1, game.TaskInfo{OpenAt: openAt}, true)
+	testMatch("Opens at "+openAt.Format("15:04"), html)
+
+	html = taskToHTML(1, game.TaskInfo{Opened: true, OpenAt: openAt}, true)
+	testNotMatch("Opens at", html)
+}
+
+func TestTaskExtrasToHTML(*testing.T) {
+
+	if taskExtrasToHTML(game.TaskInfo{}) != "" {
+		panic("extras of task without hints and files")
+	}
+
+	html := taskExtrasToHTML(game.TaskInfo{Slug: "web1",
+		Hints: "look at <headers>\nand cookies",
+		Files: []string{"web1/files/src/app.py"}})
+
+	testMatch("look at &lt;headers&gt;<br>and cookies", html)
+	testMatch(regexp.QuoteMeta(`href="/file?id=web1&name=`+
+		`web1%2Ffiles%2Fsrc%2Fapp.py"`), html)
+	testMatch(regexp.QuoteMeta(">app.py<"), html)
 }
 
 func TestCategoryToHTML(*testing.T) {
@@ -21,6 +21,8 @@ var l10nMap = map[string]string{
 	contestNotStarted:        "остановлен",
 	contestRunning:           "запущен",
 	contestCompleted:         "завершен",
+	contestPaused:            "приостановлен",
+	"scoreboard frozen":      "таблица заморожена",
 
 	"Scoreboard": "Турнирная таблица",
 	"Tasks":      "Задачи",
@@ -36,6 +38,11 @@ var l10nMap = map[string]string{
 	"Solved":       "Флаг принят",
 	"Invalid flag": "Неправильный флаг",
 
+	"Penalty for wrong flags": "Штраф за неправильные флаги",
+	" attempts)":              " попыток)",
+
+	"Opens at": "Откроется в",
+
 	`btn-submit">Submit</button`: `btn-submit">Отправить</button`,
 	`placeholder="Flag"`:         `placeholder="Флаг"`,
 }
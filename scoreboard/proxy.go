@@ -0,0 +1,158 @@
+/**
+ * @file proxy.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief client address behind reverse proxy
+ *
+ * Contain functions for get address of client from header set by trusted
+ * proxies (one of Forwarded, X-Forwarded-For, X-Real-IP)
+ */
+
+package scoreboard
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var trustedProxies []*net.IPNet
+
+// proxyHeader is the only header with client address, others can be set
+// by client itself
+var proxyHeader = "X-Real-IP"
+
+// loopback proxies trusted if scoreboard under proxy and no proxies set
+var loopbackProxies = []string{"127.0.0.0/8", "::1/128"}
+
+// ParseCIDRs parse networks, single address is network with one address
+func ParseCIDRs(cidrs []string) (nets []*net.IPNet, err error) {
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			if strings.Contains(cidr, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+
+		var ipnet *net.IPNet
+		_, ipnet, err = net.ParseCIDR(cidr)
+		if err != nil {
+			return
+		}
+
+		nets = append(nets, ipnet)
+	}
+	return
+}
+
+// SetTrustedProxies set networks of proxies allowed to pass client address
+func SetTrustedProxies(cidrs []string) (err error) {
+	trustedProxies, err = ParseCIDRs(cidrs)
+	return
+}
+
+// SetProxyHeader set header with client address (Forwarded,
+// X-Forwarded-For or X-Real-IP), X-Real-IP if empty
+func SetProxyHeader(header string) (err error) {
+	switch strings.ToLower(header) {
+	case "", "x-real-ip":
+		proxyHeader = "X-Real-IP"
+	case "x-forwarded-for":
+		proxyHeader = "X-Forwarded-For"
+	case "forwarded":
+		proxyHeader = "Forwarded"
+	default:
+		err = errors.New("Unknown proxy header " + header)
+	}
+	return
+}
+
+func isTrustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, ipnet := range trustedProxies {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stripPort remove port and brackets from address
+func stripPort(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return strings.Trim(addr, "[]")
+	}
+	return host
+}
+
+// forwardedChain returns addresses of client and proxies in order of
+// forwarding, only proxy header is read
+func forwardedChain(r *http.Request) (chain []string) {
+
+	switch proxyHeader {
+	case "Forwarded":
+		for _, header := range r.Header.Values("Forwarded") {
+			for _, element := range strings.Split(header, ",") {
+				chain = append(chain, forwardedFor(element)...)
+			}
+		}
+	case "X-Forwarded-For":
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, addr := range strings.Split(header, ",") {
+				addr = strings.TrimSpace(addr)
+				if addr != "" {
+					chain = append(chain, stripPort(addr))
+				}
+			}
+		}
+	default:
+		if addr := r.Header.Get("X-Real-IP"); addr != "" {
+			chain = append(chain, stripPort(addr))
+		}
+	}
+
+	return
+}
+
+// forwardedFor returns address from for parameter of Forwarded element
+func forwardedFor(element string) (addrs []string) {
+	for _, pair := range strings.Split(element, ";") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+			addrs = append(addrs, stripPort(strings.Trim(kv[1], `"`)))
+		}
+	}
+	return
+}
+
+// getClientAddr returns address of client, headers are used only if
+// request came from trusted proxy, chain of proxies is checked from
+// nearest one
+func getClientAddr(r *http.Request) (clientAddr string) {
+
+	clientAddr = stripPort(r.RemoteAddr)
+
+	if !isTrustedProxy(clientAddr) {
+		return
+	}
+
+	chain := forwardedChain(r)
+	for i := len(chain) - 1; i >= 0; i-- {
+		clientAddr = chain[i]
+		if !isTrustedProxy(clientAddr) {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,95 @@
+/**
+ * @file proxy_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ */
+
+package scoreboard
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+func checkClientAddr(remote string, headers map[string]string,
+	addr string) {
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = remote
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+
+	clientAddr := getClientAddr(r)
+	if clientAddr != addr {
+		panic(fmt.Sprintf("Invalid client addr: %s instead %s",
+			clientAddr, addr))
+	}
+}
+
+func TestClientAddr(*testing.T) {
+
+	err := SetTrustedProxies([]string{"10.0.0.0/8", "::1"})
+	if err != nil {
+		panic(err)
+	}
+	defer SetTrustedProxies(nil)
+
+	// direct connection can not spoof address
+	checkClientAddr("1.2.3.4:1234",
+		map[string]string{"X-Real-IP": "5.6.7.8"}, "1.2.3.4")
+
+	checkClientAddr("10.0.0.1:1234",
+		map[string]string{"X-Real-IP": "5.6.7.8"}, "5.6.7.8")
+
+	// header injected by client behind proxy is not read
+	checkClientAddr("10.0.0.1:1234",
+		map[string]string{"X-Real-IP": "5.6.7.8",
+			"X-Forwarded-For": "6.6.6.6", "Forwarded": "for=6.6.6.6"},
+		"5.6.7.8")
+
+	checkClientAddr("10.0.0.1:1234", nil, "10.0.0.1")
+
+	err = SetProxyHeader("x-forwarded-for")
+	if err != nil {
+		panic(err)
+	}
+	defer SetProxyHeader("")
+
+	// spoofed first address of chain is ignored
+	checkClientAddr("10.0.0.1:1234",
+		map[string]string{"X-Forwarded-For": "6.6.6.6, 5.6.7.8, 10.0.0.2",
+			"X-Real-IP": "7.7.7.7"},
+		"5.6.7.8")
+
+	err = SetProxyHeader("Forwarded")
+	if err != nil {
+		panic(err)
+	}
+
+	checkClientAddr("[::1]:1234",
+		map[string]string{"Forwarded": `for=6.6.6.6, ` +
+			`for="[2001:db8::1]:4711";proto=https, for=10.0.0.2`,
+			"X-Forwarded-For": "7.7.7.7"},
+		"2001:db8::1")
+
+	err = SetProxyHeader("X-Client-IP")
+	if err == nil {
+		panic("Unknown proxy header accepted")
+	}
+}
+
+func TestParseCIDRs(*testing.T) {
+
+	_, err := ParseCIDRs([]string{"10.0.0.0/8", "127.0.0.1", "::1"})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = ParseCIDRs([]string{"10.0.0.0/33"})
+	if err == nil {
+		panic("Invalid network parsed")
+	}
+}
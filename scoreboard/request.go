@@ -0,0 +1,48 @@
+/**
+ * @file request.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief logging of requests
+ *
+ * Contain functions for mark requests with id and get loggers of request
+ */
+
+package scoreboard
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/jollheef/henhouse/logging"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// withRequestID set id of request to context and response header, id
+// from trusted proxy is kept
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > 64 ||
+			!isTrustedProxy(stripPort(r.RemoteAddr)) {
+			id = logging.NewRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+
+		ctx := logging.WithRequestID(r.Context(), id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestLog returns application logger of request
+func requestLog(r *http.Request) *slog.Logger {
+	return slog.With("request_id", logging.RequestID(r.Context()))
+}
+
+// auditLog returns audit logger of request
+func auditLog(r *http.Request) *slog.Logger {
+	return logging.Audit.With("request_id", logging.RequestID(r.Context()),
+		"addr", getClientAddr(r))
+}
@@ -0,0 +1,61 @@
+/**
+ * @file request_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ */
+
+package scoreboard
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jollheef/henhouse/logging"
+)
+
+func requestID(remote, header string) (id string) {
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = remote
+	if header != "" {
+		r.Header.Set(requestIDHeader, header)
+	}
+
+	w := httptest.NewRecorder()
+
+	withRequestID(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			id = logging.RequestID(r.Context())
+		})).ServeHTTP(w, r)
+
+	if w.Header().Get(requestIDHeader) != id {
+		panic(errors.New("Request id in header differs from context"))
+	}
+
+	return
+}
+
+func TestRequestID(*testing.T) {
+
+	err := SetTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		panic(err)
+	}
+	defer SetTrustedProxies(nil)
+
+	id := requestID("1.2.3.4:1234", "")
+	if id == "" || id == requestID("1.2.3.4:1234", "") {
+		panic(errors.New("Request id is not unique"))
+	}
+
+	if requestID("1.2.3.4:1234", "pewpew") == "pewpew" {
+		panic(errors.New("Request id accepted from untrusted client"))
+	}
+
+	if requestID("10.0.0.1:1234", "pewpew") != "pewpew" {
+		panic(errors.New("Request id of trusted proxy is not kept"))
+	}
+}
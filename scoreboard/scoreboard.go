@@ -11,15 +11,22 @@
 package scoreboard
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
+	"mime"
 	"net/http"
+	"net/url"
+	"path/filepath"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fiam/gounidecode/unidecode"
 	"github.com/jollheef/henhouse/game"
+	"github.com/jollheef/henhouse/metrics"
 	"golang.org/x/net/websocket"
 )
 
@@ -28,27 +35,104 @@ const (
 	contestNotStarted        = "not started"
 	contestRunning           = "running"
 	contestCompleted         = "completed"
+	contestPaused            = "paused"
 )
 
 var (
 	gameShim      *game.Game
 	contestStatus string
 	scoreCache    []game.TeamScoreInfo
+	// Real scores of teams if public scoreboard is frozen
+	realScoreCache map[int]int
+	// Canceled on shutdown of scoreboard
+	serverCtx = context.Background()
+	// Opened websockets
+	websockets sync.WaitGroup
 )
 
+// Timeouts can be changed while scoreboard is running
+type Timeouts struct {
+	Info       time.Duration // between update info through websocket
+	Scoreboard time.Duration // between update scoreboard through websocket
+	Tasks      time.Duration // between update tasks through websocket
+	Flag       time.Duration // between send flags
+	Recalc     time.Duration // between update scoreboard
+}
+
+var timeouts atomic.Pointer[Timeouts]
+
+func init() {
+	timeouts.Store(&Timeouts{
+		Info:       time.Second,
+		Scoreboard: time.Second,
+		Tasks:      time.Second,
+		Flag:       time.Second,
+		Recalc:     time.Second,
+	})
+}
+
+// GetTimeouts returns current timeouts
+func GetTimeouts() Timeouts {
+	return *timeouts.Load()
+}
+
+// SetTimeouts set timeouts, it is safe to call while scoreboard is running
+func SetTimeouts(t Timeouts) {
+	timeouts.Store(&t)
+}
+
 var (
-	// InfoTimeout timeout between update info through websocket
-	InfoTimeout = time.Second
-	// ScoreboardTimeout timeout between update scoreboard through websocket
-	ScoreboardTimeout = time.Second
-	// TasksTimeout timeout between update tasks through websocket
-	TasksTimeout = time.Second
-	// FlagTimeout timeout between send flags
-	FlagTimeout = time.Second
-	// ScoreboardRecalcTimeout timeout between update scoreboard
-	ScoreboardRecalcTimeout = time.Second
+	// Location is timezone of event used for output of times
+	Location = time.Local
+	// ReadTimeout max duration of read request
+	ReadTimeout = 10 * time.Second
+	// WriteTimeout max duration of write response
+	WriteTimeout = 30 * time.Second
+	// IdleTimeout max duration of wait next request on keep-alive
+	IdleTimeout = 2 * time.Minute
+	// ShutdownTimeout max duration of wait for requests and websockets
+	// on shutdown
+	ShutdownTimeout = 10 * time.Second
+	// TaskDir contains files of tasks served to teams
+	TaskDir string
 )
 
+var (
+	flagSubmissions = metrics.NewCounter("henhouse_flag_submissions_total",
+		"Flag submissions by verdict (solved, uncounted, wrong, "+
+			"rejected).", "verdict")
+	taskSolves = metrics.NewCounter("henhouse_task_solves_total",
+		"Solves of tasks counted on scoreboard by task ID.", "task")
+	connectedWebsockets = metrics.NewGauge("henhouse_websockets",
+		"Connected websockets by channel.", "channel")
+)
+
+// sleep returns false if scoreboard is shutting down before timeout
+func sleep(timeout time.Duration) bool {
+	select {
+	case <-serverCtx.Done():
+		return false
+	case <-time.After(timeout):
+		return true
+	}
+}
+
+// trackWebsocket allow wait for close of websockets on shutdown and
+// count connected websockets of channel
+func trackWebsocket(channel string,
+	handler websocket.Handler) websocket.Handler {
+
+	return func(ws *websocket.Conn) {
+		websockets.Add(1)
+		defer websockets.Done()
+
+		connectedWebsockets.Inc(channel)
+		defer connectedWebsockets.Dec(channel)
+
+		handler(ws)
+	}
+}
+
 func durationToHMS(d time.Duration) string {
 
 	sec := int(d.Seconds())
@@ -64,26 +148,34 @@ func durationToHMS(d time.Duration) string {
 
 func getInfo() string {
 
-	var left time.Duration
 	var btnType string
 
-	now := time.Now()
+	state, err := gameShim.State()
+	if err != nil {
+		slog.Error("Get game state fail", "err", err)
+		return fmt.Sprintf(`<span id="game_status-stop">contest %s</span>`,
+			contestStateNotAvailable)
+	}
+
+	left, started, ended := game.Left(state, time.Now())
+
+	if state.Paused && !ended {
+
+		contestStatus = contestPaused
+		btnType = "stop"
 
-	if now.Before(gameShim.Start) {
+	} else if !started {
 
 		contestStatus = contestNotStarted
-		left = gameShim.Start.Sub(now)
 		btnType = "stop"
 
-	} else if now.Before(gameShim.End) {
+	} else if !ended {
 
 		contestStatus = contestRunning
-		left = gameShim.End.Sub(now)
 		btnType = "run"
 
 	} else {
 		contestStatus = contestCompleted
-		left = 0
 		btnType = "stop"
 	}
 
@@ -91,10 +183,20 @@ func getInfo() string {
 		btnType, contestStatus)
 
 	if left != 0 {
-		info += fmt.Sprintf(`<span id="timer">%s</span>`,
+		until := state.End
+		if !started {
+			until = state.Start
+		}
+
+		info += fmt.Sprintf(`<span id="timer" title="%s">%s</span>`,
+			until.In(Location).Format("2006-01-02 15:04 MST"),
 			durationToHMS(left))
 	}
 
+	if game.Frozen(state, time.Now()) {
+		info += `<span id="frozen">scoreboard frozen</span>`
+	}
+
 	return info
 }
 
@@ -108,7 +210,9 @@ func infoHandler(ws *websocket.Conn) {
 			return
 		}
 
-		time.Sleep(InfoTimeout)
+		if !sleep(GetTimeouts().Info) {
+			return
+		}
 	}
 }
 
@@ -142,7 +246,9 @@ func scoreboardHandler(ws *websocket.Conn) {
 			}
 		}
 
-		time.Sleep(ScoreboardTimeout)
+		if !sleep(GetTimeouts().Scoreboard) {
+			return
+		}
 	}
 }
 
@@ -155,9 +261,15 @@ func scoreboardHTML(teamID int) (result string) {
 
 	result += "<tbody>"
 
+	realScores := realScoreCache
+
 	for n, teamScore := range scoreCache {
 		if teamScore.ID == teamID {
 			result += `<tr class="self_team">`
+			if score, ok := realScores[teamID]; ok {
+				// team always see own real score
+				teamScore.Score = score
+			}
 		} else {
 			result += `<tr>`
 		}
@@ -175,32 +287,61 @@ func scoreboardHTML(teamID int) (result string) {
 	return
 }
 
-func scoreboardUpdater(game *game.Game, updateTimeout time.Duration) {
+func realScores(g *game.Game) (scores map[int]int, err error) {
 
-	for {
-		err := game.RecalcScoreboard()
-		if err != nil {
-			log.Println("Recalc scoreboard fail:", err)
-			time.Sleep(updateTimeout)
-			continue
-		}
+	state, err := g.State()
+	if err != nil || !game.Frozen(state, time.Now()) {
+		return
+	}
 
-		scoreCache, err = game.Scoreboard()
-		if err != nil {
-			log.Println("Get scoreboard fail:", err)
-			time.Sleep(updateTimeout)
-			continue
-		}
+	all, err := g.RealScoreboard()
+	if err != nil {
+		return
+	}
+
+	scores = make(map[int]int)
+	for _, s := range all {
+		scores[s.ID] = s.Score
+	}
+
+	return
+}
+
+func updateScoreboard(g *game.Game) {
+
+	err := g.RecalcScoreboard()
+	if err != nil {
+		slog.Error("Recalc scoreboard fail", "err", err)
+		return
+	}
+
+	scoreCache, err = g.Scoreboard()
+	if err != nil {
+		slog.Error("Get scoreboard fail", "err", err)
+		return
+	}
+
+	realScoreCache, err = realScores(g)
+	if err != nil {
+		slog.Error("Get real scoreboard fail", "err", err)
+	}
+}
+
+func scoreboardUpdater(g *game.Game) {
+
+	updateScoreboard(g)
 
-		time.Sleep(updateTimeout)
+	// timeout can be changed at runtime
+	for sleep(GetTimeouts().Recalc) {
+		updateScoreboard(g)
 	}
 }
 
 func tasksHTML(teamID int, ru bool) (result string) {
 
-	cats, err := gameShim.Tasks()
+	cats, err := gameShim.TeamTasks(teamID)
 	if err != nil {
-		log.Println("Get tasks fail:", err)
+		slog.Error("Get tasks fail", "err", err)
 	}
 
 	for _, cat := range cats {
@@ -240,27 +381,29 @@ func tasksHandler(ws *websocket.Conn) {
 			}
 		}
 
-		time.Sleep(TasksTimeout)
+		if !sleep(GetTimeouts().Tasks) {
+			return
+		}
 	}
 }
 
-func taskHandler(w http.ResponseWriter, r *http.Request) {
+// teamTask returns task from request as seen by team, task is closed if
+// it does not exist
+func teamTask(r *http.Request, teamID int) (task game.TaskInfo, err error) {
 
-	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	id, err := gameShim.TaskID(r.URL.Query().Get("id"))
 	if err != nil {
-		log.Println("Atoi fail:", err)
-		http.Redirect(w, r, "/", 307)
+		requestLog(r).Warn("Get task id fail", "err", err)
 		return
 	}
 
-	cats, err := gameShim.Tasks()
+	cats, err := gameShim.TeamTasks(teamID)
 	if err != nil {
-		log.Println("Get tasks fail:", err)
-		http.Redirect(w, r, "/", 307)
+		requestLog(r).Error("Get tasks fail", "err", err)
 		return
 	}
 
-	task := game.TaskInfo{ID: id, Opened: false}
+	task = game.TaskInfo{ID: id, Opened: false}
 
 	for _, c := range cats {
 		for _, t := range c.TasksInfo {
@@ -271,16 +414,22 @@ func taskHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if !task.Opened {
+	return
+}
+
+func taskHandler(w http.ResponseWriter, r *http.Request) {
+
+	teamID := getTeamID(r)
+
+	task, err := teamTask(r, teamID)
+	if err != nil || !task.Opened {
 		// Try to see closed task -> gtfo
 		http.Redirect(w, r, "/", 307)
 		return
 	}
 
-	teamID := getTeamID(r)
-
 	flagSubmitFormat := `<br>` +
-		`<form class="input-group" action="/flag?id=%d" method="post">` +
+		`<form class="input-group" action="/flag?id=%s" method="post">` +
 		`<input class="form-control float-left" name="flag" value="" placeholder="Flag">` +
 		`<span class="input-group-btn">` +
 		`<button class="btn btn-submit">Submit</button>` +
@@ -291,12 +440,22 @@ func taskHandler(w http.ResponseWriter, r *http.Request) {
 	if taskSolvedBy(task, teamID) {
 		submitForm = "Already solved"
 	} else {
-		submitForm = fmt.Sprintf(flagSubmitFormat, task.ID)
+		submitForm = fmt.Sprintf(flagSubmitFormat,
+			url.QueryEscape(taskKey(task)))
+	}
+
+	penalty, wrong, err := gameShim.TaskPenalty(teamID, task.ID)
+	if err != nil {
+		requestLog(r).Error("Get task penalty fail", "err", err)
+	} else if penalty != 0 {
+		submitForm += fmt.Sprintf(`<div class="penalty">`+
+			`Penalty for wrong flags: -%d (%d attempts)</div>`,
+			penalty, wrong)
 	}
 
 	tmpl, err := getTmpl("task")
 	if err != nil {
-		log.Println(err)
+		requestLog(r).Error("Get template fail", "err", err)
 		return
 	}
 
@@ -311,10 +470,39 @@ func taskHandler(w http.ResponseWriter, r *http.Request) {
 		author = unidecode.Unidecode(task.Author)
 	}
 
+	desc += taskExtrasToHTML(task)
+
 	fmt.Fprintf(w, l10n(r, tmpl), name, desc,
 		author, l10n(r, submitForm))
 }
 
+// fileHandler serve file of task opened for team, only files listed in
+// task are served
+func fileHandler(w http.ResponseWriter, r *http.Request) {
+
+	task, err := teamTask(r, getTeamID(r))
+	if err != nil || !task.Opened {
+		http.Redirect(w, r, "/", 307)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+
+	for _, file := range task.Files {
+		if file != name {
+			continue
+		}
+
+		w.Header().Set("Content-Disposition",
+			mime.FormatMediaType("attachment",
+				map[string]string{"filename": filepath.Base(file)}))
+		http.ServeFile(w, r, filepath.Join(TaskDir, file))
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
 func flagHandler(w http.ResponseWriter, r *http.Request) {
 
 	if r.Method != "POST" {
@@ -322,9 +510,9 @@ func flagHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	taskID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	taskID, err := gameShim.TaskID(r.URL.Query().Get("id"))
 	if err != nil {
-		log.Println("Atoi fail:", err)
+		requestLog(r).Warn("Get task id fail", "err", err)
 		http.Redirect(w, r, "/", 307)
 		return
 	}
@@ -333,9 +521,18 @@ func flagHandler(w http.ResponseWriter, r *http.Request) {
 
 	teamID := getTeamID(r)
 
-	solved, err := gameShim.Solve(teamID, taskID, flag)
+	solved, counted, err := gameShim.Submit(teamID, taskID, flag)
 	if err != nil {
 		solved = false
+		flagSubmissions.Inc("rejected")
+	} else if counted {
+		flagSubmissions.Inc("solved")
+		taskSolves.Inc(strconv.Itoa(taskID))
+	} else if solved {
+		// already solved, test team or game is not running
+		flagSubmissions.Inc("uncounted")
+	} else {
+		flagSubmissions.Inc("wrong")
 	}
 
 	var solvedMsg string
@@ -345,14 +542,18 @@ func flagHandler(w http.ResponseWriter, r *http.Request) {
 		solvedMsg = `<div class="flag_status invalid">Invalid flag</div>`
 	}
 
-	log.Printf("Team ID: %d, Task ID: %d, Flag: %s, Result: %s\n",
-		teamID, taskID, flag, solvedMsg)
+	attrs := []any{"team", teamID, "task", taskID, "flag", flag,
+		"solved", solved}
+	if err != nil {
+		attrs = append(attrs, "err", err)
+	}
+	auditLog(r).Info("Flag", attrs...)
 
-	time.Sleep(FlagTimeout)
+	time.Sleep(GetTimeouts().Flag)
 
 	tmpl, err := getTmpl("flag")
 	if err != nil {
-		log.Println(err)
+		requestLog(r).Error("Get template fail", "err", err)
 		return
 	}
 
@@ -373,7 +574,7 @@ func handleStaticFileSimple(file, wwwPath string) {
 func signinHandler(w http.ResponseWriter, r *http.Request) {
 	tmpl, err := getTmpl("auth")
 	if err != nil {
-		log.Println(err)
+		requestLog(r).Error("Get template fail", "err", err)
 		return
 	}
 	fmt.Fprint(w, l10n(r, tmpl))
@@ -388,7 +589,7 @@ func newsHandler(w http.ResponseWriter, r *http.Request) {
 		tmpl, err = getTmplWoCache("news.en")
 	}
 	if err != nil {
-		log.Println(err)
+		requestLog(r).Error("Get template fail", "err", err)
 		return
 	}
 	fmt.Fprint(w, l10n(r, tmpl))
@@ -403,28 +604,62 @@ func sponsorsHandler(w http.ResponseWriter, r *http.Request) {
 		tmpl, err = getTmpl("sponsors.en")
 	}
 	if err != nil {
-		log.Println(err)
+		requestLog(r).Error("Get template fail", "err", err)
 		return
 	}
 	fmt.Fprint(w, l10n(r, tmpl))
 }
 
-// Scoreboard implements web scoreboard
-func Scoreboard(database *sql.DB, game *game.Game,
+// shutdown wait for requests and websockets, websockets are closed after
+// cancel of serverCtx
+func shutdown(server *http.Server) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	err := server.Shutdown(ctx)
+	if err != nil {
+		slog.Error("Shutdown scoreboard fail", "err", err)
+		return
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		websockets.Wait()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-ctx.Done():
+		slog.Warn("Websockets are not closed until timeout")
+	}
+}
+
+// Scoreboard implements web scoreboard, scoreboard is gracefully stopped
+// after cancel of context
+func Scoreboard(ctx context.Context, database *sql.DB, game *game.Game,
 	wwwPath, tmpltsPath, addr string, proxy bool) (err error) {
 
+	serverCtx = ctx
 	contestStatus = contestStateNotAvailable
 	gameShim = game
 	templatePath = tmpltsPath
-	underProxy = proxy
+
+	if proxy && len(trustedProxies) == 0 {
+		trustedProxies, err = ParseCIDRs(loopbackProxies)
+		if err != nil {
+			return
+		}
+	}
 
 	scoreCache, err = gameShim.Scoreboard()
 	if err != nil {
-		log.Println("Get scoreboard fail:", err)
+		slog.Error("Get scoreboard fail", "err", err)
 		return
 	}
 
-	go scoreboardUpdater(game, ScoreboardRecalcTimeout)
+	go scoreboardUpdater(game)
 
 	// Static files
 	handleStaticFileSimple("/css/style.css", wwwPath)
@@ -449,12 +684,13 @@ func Scoreboard(database *sql.DB, game *game.Game,
 	http.Handle("/sponsors.html", authorized(database, http.HandlerFunc(sponsorsHandler)))
 
 	// Websocket
-	http.Handle("/scoreboard", authorized(database, websocket.Handler(scoreboardHandler)))
-	http.Handle("/info", authorized(database, websocket.Handler(infoHandler)))
-	http.Handle("/tasks", authorized(database, websocket.Handler(tasksHandler)))
+	http.Handle("/scoreboard", authorized(database, trackWebsocket("scoreboard", scoreboardHandler)))
+	http.Handle("/info", authorized(database, trackWebsocket("info", infoHandler)))
+	http.Handle("/tasks", authorized(database, trackWebsocket("tasks", tasksHandler)))
 
 	// Post
 	http.Handle("/task", authorized(database, http.HandlerFunc(taskHandler)))
+	http.Handle("/file", authorized(database, http.HandlerFunc(fileHandler)))
 	http.Handle("/flag", authorized(database, http.HandlerFunc(flagHandler)))
 
 	http.HandleFunc("/auth.php", http.HandlerFunc(
@@ -462,7 +698,52 @@ func Scoreboard(database *sql.DB, game *game.Game,
 			authHandler(database, w, r)
 		}))
 
-	log.Println("Launching scoreboard at", addr)
+	server := &http.Server{
+		Addr:         addr,
+		ReadTimeout:  ReadTimeout,
+		WriteTimeout: WriteTimeout,
+		IdleTimeout:  IdleTimeout,
+		Handler:      withRequestID(http.DefaultServeMux),
+	}
+
+	var redirect *http.Server
 
-	return http.ListenAndServe(addr, nil)
+	if tlsEnabled() {
+		err = loadCertificate()
+		if err != nil {
+			return
+		}
+
+		server.TLSConfig = tlsConfig()
+
+		if RedirectAddr != "" {
+			redirect = serveRedirect(addr)
+		}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		slog.Info("Shutdown scoreboard")
+		if redirect != nil {
+			redirect.Close()
+		}
+		shutdown(server)
+		close(stopped)
+	}()
+
+	slog.Info("Launching scoreboard", "addr", addr, "https", tlsEnabled())
+
+	if tlsEnabled() {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+
+	if err == http.ErrServerClosed {
+		<-stopped
+		err = nil
+	}
+
+	return
 }
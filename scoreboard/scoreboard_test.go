@@ -9,6 +9,7 @@
 package scoreboard
 
 import (
+	stdcontext "context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -102,6 +103,23 @@ func testNotMatch(pattern, s string) {
 	}
 }
 
+func TestSleep(*testing.T) {
+
+	if !sleep(time.Millisecond) {
+		panic(errors.New("Sleep interrupted without shutdown"))
+	}
+
+	ctx, cancel := stdcontext.WithCancel(stdcontext.Background())
+	cancel()
+
+	serverCtx = ctx
+	defer func() { serverCtx = stdcontext.Background() }()
+
+	if sleep(time.Hour) {
+		panic(errors.New("Sleep not interrupted by shutdown"))
+	}
+}
+
 func TestGetInfo(*testing.T) {
 
 	database, err := db.InitDatabase(dbPath)
@@ -119,12 +137,24 @@ func TestGetInfo(*testing.T) {
 		return
 	}
 
-	game, err := game.NewGame(database, startTime, endTime, float64(len(teams)))
+	g, err := game.NewGame(database, startTime, endTime, float64(len(teams)))
 	if err != nil {
 		panic(err)
 	}
 
-	gameShim = &game
+	gameShim = &g
+
+	err = game.Pause(database)
+	if err != nil {
+		panic(err)
+	}
+
+	testMatch(contestPaused, getInfo())
+
+	err = game.Resume(database)
+	if err != nil {
+		panic(err)
+	}
 
 	info := getInfo()
 
@@ -165,8 +195,8 @@ func addTestData(database *sql.DB, nteams, ncategories, ntasks int,
 
 	for i := 0; i < nteams; i++ {
 
-		team := db.Team{255, fmt.Sprintf("team%d", i),
-			"e", "d", "l", false}
+		team := db.Team{ID: 255, Name: fmt.Sprintf("team%d", i),
+			Email: "e", Desc: "d", Token: "l"}
 
 		err = db.AddTeam(database, &team)
 		if err != nil {
@@ -370,6 +400,13 @@ func checkScoreboard(database *sql.DB, game *game.Game, addr, validFlag string,
 
 	ws.Close()
 
+	for taskID := 10; taskID < 15; taskID++ {
+		err = db.SetOpened(database, taskID, true)
+		if err != nil {
+			return
+		}
+	}
+
 	err = solveTasks(game, validFlag, 10, 15)
 	if err != nil {
 		return
@@ -430,18 +467,20 @@ func TestScoreboard(*testing.T) {
 
 	addr := "localhost:8080"
 
-	err = game.Run()
+	err = game.Run(stdcontext.Background())
 	if err != nil {
 		panic(err)
 	}
 
-	ScoreboardRecalcTimeout = time.Second / 10
+	recalc := GetTimeouts()
+	recalc.Recalc = time.Second / 10
+	SetTimeouts(recalc)
 	
 	proxy := false
 
 	go func() {
 		_, filename, _, _ := runtime.Caller(0)
-		err = Scoreboard(database, &game, filepath.Dir(filename)+"/www",
+		err = Scoreboard(stdcontext.Background(), database, &game, filepath.Dir(filename)+"/www",
 			filepath.Dir(filename)+"/templates", addr, proxy)
 		if err != nil {
 			panic(err)
@@ -485,7 +524,7 @@ func TestTaskHandler(*testing.T) {
 		panic("wrong status")
 	}
 
-	err = game.Run()
+	err = game.Run(stdcontext.Background())
 	if err != nil {
 		panic(err)
 	}
@@ -560,7 +599,7 @@ func TestFlagHandler(*testing.T) {
 	}
 
 	// 4
-	err = game.Run()
+	err = game.Run(stdcontext.Background())
 	if err != nil {
 		panic(err)
 	}
@@ -12,14 +12,13 @@ package scoreboard
 
 import (
 	"fmt"
-	"log"
 	"net/http"
 )
 
 func outerScoreboard(w http.ResponseWriter, r *http.Request) {
 	tmpl, err := getTmpl("outer_scoreboard")
 	if err != nil {
-		log.Println(err)
+		requestLog(r).Error("Get template fail", "err", err)
 		return
 	}
 
@@ -32,7 +31,7 @@ func innerScoreboard(w http.ResponseWriter, r *http.Request) {
 
 	tmpl, err := getTmpl("scoreboard")
 	if err != nil {
-		log.Println(err)
+		requestLog(r).Error("Get template fail", "err", err)
 		return
 	}
 
@@ -47,7 +46,7 @@ func staticTasks(w http.ResponseWriter, r *http.Request) {
 
 	tmpl, err := getTmpl("tasks")
 	if err != nil {
-		log.Println(err)
+		requestLog(r).Error("Get template fail", "err", err)
 		return
 	}
 
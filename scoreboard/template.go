@@ -8,13 +8,30 @@
 
 package scoreboard
 
-import "io/ioutil"
+import (
+	"io/ioutil"
+	"sync"
+)
 
 var templatePath string
 
-var cache = make(map[string]string)
+var (
+	cache     = make(map[string]string)
+	cacheLock sync.Mutex
+)
+
+// ClearTemplateCache force reread of templates from disk
+func ClearTemplateCache() {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+
+	cache = make(map[string]string)
+}
 
 func getTmpl(name string) (s string, err error) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+
 	s, ok := cache[name]
 	if ok {
 		return
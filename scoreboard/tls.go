@@ -0,0 +1,114 @@
+/**
+ * @file tls.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ * @brief https support
+ *
+ * Contain functions for load certificate and redirect from http to https
+ */
+
+package scoreboard
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+)
+
+var (
+	// TLSCertFile and TLSKeyFile enable https if both set
+	TLSCertFile, TLSKeyFile string
+	// RedirectAddr is address of http server redirects to https
+	RedirectAddr string
+)
+
+var (
+	certificate     *tls.Certificate
+	certificateLock sync.RWMutex
+)
+
+func tlsEnabled() bool {
+	return TLSCertFile != "" && TLSKeyFile != ""
+}
+
+func loadCertificate() (err error) {
+
+	cert, err := tls.LoadX509KeyPair(TLSCertFile, TLSKeyFile)
+	if err != nil {
+		return
+	}
+
+	certificateLock.Lock()
+	defer certificateLock.Unlock()
+
+	certificate = &cert
+	return
+}
+
+// ReloadCertificate reread certificate and key without restart, does
+// nothing if https is disabled
+func ReloadCertificate() (err error) {
+	if !tlsEnabled() {
+		return
+	}
+	return loadCertificate()
+}
+
+func getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certificateLock.RLock()
+	defer certificateLock.RUnlock()
+
+	return certificate, nil
+}
+
+func tlsConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: getCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+}
+
+// httpsRedirect redirects to same url on https server listen at addr
+func httpsRedirect(addr string) http.Handler {
+
+	_, port, _ := net.SplitHostPort(addr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(),
+			http.StatusMovedPermanently)
+	})
+}
+
+// serveRedirect start http server redirects to https server listen at addr
+func serveRedirect(addr string) (server *http.Server) {
+
+	server = &http.Server{
+		Addr:         RedirectAddr,
+		Handler:      httpsRedirect(addr),
+		ReadTimeout:  ReadTimeout,
+		WriteTimeout: WriteTimeout,
+		IdleTimeout:  IdleTimeout,
+	}
+
+	go func() {
+		slog.Info("Redirect to https", "addr", RedirectAddr)
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			slog.Error("Redirect server fail", "err", err)
+		}
+	}()
+
+	return
+}
@@ -0,0 +1,51 @@
+/**
+ * @file tls_test.go
+ * @author Mikhail Klementyev jollheef<AT>riseup.net
+ * @license GNU AGPLv3
+ * @date October, 2026
+ */
+
+package scoreboard
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func checkRedirect(addr, url, location string) {
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", url, nil)
+
+	httpsRedirect(addr).ServeHTTP(w, r)
+
+	if w.Code != http.StatusMovedPermanently {
+		panic(fmt.Sprint("Invalid code ", w.Code))
+	}
+
+	if w.Header().Get("Location") != location {
+		panic(fmt.Sprint("Invalid redirect to ", w.Header().Get("Location"),
+			" instead ", location))
+	}
+}
+
+func TestHTTPSRedirect(*testing.T) {
+
+	checkRedirect(":443", "http://ctf.example.com/tasks.html?id=1",
+		"https://ctf.example.com/tasks.html?id=1")
+
+	checkRedirect(":8443", "http://ctf.example.com:8000/",
+		"https://ctf.example.com:8443/")
+}
+
+func TestReloadCertificateDisabled(*testing.T) {
+
+	TLSCertFile, TLSKeyFile = "", ""
+
+	err := ReloadCertificate()
+	if err != nil {
+		panic(err)
+	}
+}